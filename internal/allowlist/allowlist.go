@@ -0,0 +1,155 @@
+package allowlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrIPNotAllowed       = errors.New("client IP is not allowed")
+	ErrContractNotAllowed = errors.New("contract is not allowed")
+	ErrInvalidEntry       = errors.New("invalid allowList entry")
+)
+
+// Config is the allowList of configuration.json. Empty lists allow
+// everything, so an unconfigured allowList keeps the API open.
+//
+// IPs holds client IP addresses or CIDR ranges. Contracts maps a network to
+// the contract addresses, or the asset and collection IDs of a Substrate
+// network, that may be checked on it; networks without an entry accept any
+// contract. TrustedProxies lists the proxies whose
+// X-Forwarded-For header is used to find the client IP.
+type Config struct {
+	IPs            []string            `json:"ips"`
+	Contracts      map[string][]string `json:"contracts"`
+	TrustedProxies []string            `json:"trustedProxies"`
+}
+
+// UnmarshalJSON also accepts the legacy form of the allowList, a plain array
+// of client IPs.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		*c = Config{}
+		return json.Unmarshal(data, &c.IPs)
+	}
+	type config Config
+	return json.Unmarshal(data, (*config)(c))
+}
+
+// List is a compiled Config. The nil List allows everything.
+type List struct {
+	nets      []*net.IPNet
+	contracts map[string]map[string]bool
+}
+
+// New validates and compiles the config. Empty entries are ignored.
+func New(config Config) (*List, error) {
+	l := &List{contracts: make(map[string]map[string]bool)}
+
+	for _, entry := range config.IPs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ipNet, err := parseIPNet(entry)
+		if err != nil {
+			return nil, err
+		}
+		l.nets = append(l.nets, ipNet)
+	}
+
+	for _, entry := range config.TrustedProxies {
+		if _, err := parseIPNet(entry); err != nil {
+			return nil, fmt.Errorf("trustedProxies: %w", err)
+		}
+	}
+
+	for network, contracts := range config.Contracts {
+		allowed := make(map[string]bool)
+		for _, contract := range contracts {
+			contract = strings.TrimSpace(contract)
+			if contract == "" {
+				continue
+			}
+			key, ok := contractKey(contract)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q on %s is not an address or an asset ID", ErrInvalidEntry, contract, network)
+			}
+			allowed[key] = true
+		}
+		if len(allowed) > 0 {
+			l.contracts[network] = allowed
+		}
+	}
+
+	return l, nil
+}
+
+// contractKey normalizes a contract address, or a u32 asset or collection ID
+// of a Substrate network.
+func contractKey(contract string) (string, bool) {
+	if common.IsHexAddress(contract) {
+		return common.HexToAddress(contract).Hex(), true
+	}
+	id, err := strconv.ParseUint(contract, 10, 32)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(id, 10), true
+}
+
+func parseIPNet(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a CIDR range", ErrInvalidEntry, entry)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q is not an IP address", ErrInvalidEntry, entry)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// AllowsIP reports whether a client IP may use the API.
+func (l *List) AllowsIP(ip string) bool {
+	if l == nil || len(l.nets) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range l.nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsContract reports whether a contract may be checked on a network.
+func (l *List) AllowsContract(network, contract string) bool {
+	if l == nil {
+		return true
+	}
+	allowed, ok := l.contracts[network]
+	if !ok {
+		return true
+	}
+	key, ok := contractKey(contract)
+	return ok && allowed[key]
+}
@@ -0,0 +1,63 @@
+package allowlist
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowsIP(t *testing.T) {
+	list, err := New(Config{IPs: []string{"", "203.0.113.7", "10.0.0.0/8", "2001:db8::/32"}})
+	assert.NoError(t, err)
+
+	assert.True(t, list.AllowsIP("203.0.113.7"))
+	assert.False(t, list.AllowsIP("203.0.113.8"))
+	assert.True(t, list.AllowsIP("10.1.2.3"))
+	assert.True(t, list.AllowsIP("2001:db8::1"))
+	assert.False(t, list.AllowsIP("not an ip"))
+
+	open, err := New(Config{IPs: []string{""}})
+	assert.NoError(t, err)
+	assert.True(t, open.AllowsIP("198.51.100.1"))
+	assert.True(t, (*List)(nil).AllowsIP("198.51.100.1"))
+
+	_, err = New(Config{IPs: []string{"10.0.0.0/33"}})
+	assert.ErrorIs(t, err, ErrInvalidEntry)
+	_, err = New(Config{TrustedProxies: []string{"proxy"}})
+	assert.ErrorIs(t, err, ErrInvalidEntry)
+}
+
+func TestAllowsContract(t *testing.T) {
+	list, err := New(Config{Contracts: map[string][]string{
+		"eth":    {"0x00000000000000000000000000000000000000c0"},
+		"trn":    {""},
+		"kusama": {"1984", "07"},
+	}})
+	assert.NoError(t, err)
+
+	assert.True(t, list.AllowsContract("eth", "0x00000000000000000000000000000000000000C0"))
+	assert.False(t, list.AllowsContract("eth", "0x00000000000000000000000000000000000000C1"))
+	assert.True(t, list.AllowsContract("trn", "0x00000000000000000000000000000000000000C1"))
+	assert.True(t, list.AllowsContract("arb", "0x00000000000000000000000000000000000000C1"))
+	assert.True(t, list.AllowsContract("kusama", "1984"))
+	assert.True(t, list.AllowsContract("kusama", "7"), "IDs should be normalized")
+	assert.False(t, list.AllowsContract("kusama", "1985"))
+	assert.False(t, list.AllowsContract("kusama", "0x00000000000000000000000000000000000000C0"))
+
+	for _, invalid := range []string{"0x1234", "4294967296", "-1"} {
+		_, err = New(Config{Contracts: map[string][]string{"eth": {invalid}}})
+		assert.ErrorIs(t, err, ErrInvalidEntry, "%q should be rejected", invalid)
+	}
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	var legacy Config
+	assert.NoError(t, json.Unmarshal([]byte(`["10.0.0.1"]`), &legacy))
+	assert.Equal(t, []string{"10.0.0.1"}, legacy.IPs)
+
+	var config Config
+	assert.NoError(t, json.Unmarshal([]byte(`{"ips": ["10.0.0.0/8"], "contracts": {"eth": ["0x00000000000000000000000000000000000000c0"]}}`), &config))
+	assert.Equal(t, []string{"10.0.0.0/8"}, config.IPs)
+	assert.Len(t, config.Contracts["eth"], 1)
+}
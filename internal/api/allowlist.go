@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/gin-gonic/gin"
+)
+
+// allowIPs rejects requests from client IPs that are not on the allowList.
+func allowIPs(list *allowlist.List) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := c.ClientIP(); !list.AllowsIP(ip) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": allowlist.ErrIPNotAllowed.Error() + ": " + ip})
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkStatus returns the HTTP status of a validateCheck error.
+func checkStatus(err error) int {
+	if errors.Is(err, allowlist.ErrContractNotAllowed) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, limits.ErrTooManyTokenIDs) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
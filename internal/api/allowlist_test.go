@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowList(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 1500})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+
+	var err error
+	shared.AllowList, err = allowlist.New(allowlist.Config{
+		IPs:       []string{"192.0.2.0/24"},
+		Contracts: map[string][]string{"eth": {strings.ToLower(token)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router = newRouter()
+
+	status, response := post(t, router, "/api/eth/erc20/1000/"+token, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["success"])
+
+	status, response = post(t, router, "/api/eth/erc20/1000/"+unlisted, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, response["error"], "contract is not allowed")
+
+	rule := rules.Rule{Or: []*rules.Rule{
+		rules.NewCheck("eth", "erc20", "1", token),
+		rules.NewCheck("eth", "erc20", "1", unlisted),
+	}}
+	status, _ = post(t, router, "/api/rule", RuleRequest{Rule: &rule, WalletRequest: WalletRequest{Wallet: walletA}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	shared.AllowList, err = allowlist.New(allowlist.Config{IPs: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router = newRouter()
+	status, response = post(t, router, "/api/eth/erc20/1000/"+token, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, response["error"], "client IP is not allowed")
+}
@@ -1,20 +1,40 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"net/http"
-
-	"github.com/FN00EU/vulcan-one/internal/erc1155"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/metadata"
+	"github.com/FN00EU/vulcan-one/internal/metrics"
+	"github.com/FN00EU/vulcan-one/internal/multicall"
+	"github.com/FN00EU/vulcan-one/internal/revert"
+	"github.com/FN00EU/vulcan-one/internal/rules"
 	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/snapshot"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/FN00EU/vulcan-one/internal/substrate"
 	"github.com/FN00EU/vulcan-one/internal/trn"
+	"github.com/FN00EU/vulcan-one/internal/units"
 	"github.com/FN00EU/vulcan-one/internal/utils"
 	"github.com/FN00EU/vulcan-one/internal/w3client"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gin-gonic/gin"
 	"github.com/lmittmann/w3"
-	"github.com/lmittmann/w3/module/eth"
 	"github.com/lmittmann/w3/w3types"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type WalletRequest struct {
@@ -26,6 +46,27 @@ type Wallets struct {
 	WalletAddresses []string `json:"wallets"`
 }
 
+// RuleRequest carries a rule expression together with the wallets to check.
+type RuleRequest struct {
+	Rule *rules.Rule `json:"rule"`
+	WalletRequest
+}
+
+const (
+	errInvalidNetwork   = "Invalid network"
+	errInvalidAmount    = "Invalid amount"
+	errInvalidAddress   = "Invalid address"
+	errInvalidStandard  = "Bad API call: Invalid 'standard'"
+	verboseMediaType    = "application/vnd.vulcan.verbose+json"
+	sourceWallet        = "wallet"
+	aggregateAny        = "any"
+	aggregateSum        = "sum"
+	errInvalidAggregate = "aggregate must be any or sum"
+
+	// maxStages bounds the batches of staged checks, see standard.Staged.
+	maxStages = 8
+)
+
 // TODO: Add command line flag for config
 func Start() {
 
@@ -38,81 +79,369 @@ func Start() {
 
 	shared.Config = config
 
+	if token := os.Getenv("VULCAN_ADMIN_TOKEN"); token != "" {
+		shared.Config.AdminToken = token
+	}
+	if secret := os.Getenv("VULCAN_SESSION_SECRET"); secret != "" {
+		shared.Config.SIWE.SessionSecret = secret
+	}
+
+	// The domain binds messages to this deployment, so it is never taken
+	// from the request.
+	if shared.Config.SIWE.SessionRequired() && shared.Config.SIWE.Domain == "" {
+		log.Fatal("siwe.required needs siwe.domain")
+	}
+
+	for _, s := range shared.Config.ValidStandards {
+		if _, ok := standard.Lookup(s); !ok && !substrate.IsStandard(s) {
+			log.Printf("Standard %s in validStandards is not registered, registered standards: %s", s, strings.Join(standard.Names(), ", "))
+		}
+	}
+
+	shared.AllowList, err = allowlist.New(shared.Config.AllowList)
+	if err != nil {
+		log.Fatal("Error loading allowList:", err)
+	}
+
+	shared.Metadata, err = metadata.New(shared.Config.Metadata)
+	if err != nil {
+		log.Fatal("Error loading metadata config:", err)
+	}
+
+	rulesFile := shared.Config.RulesFile
+	if rulesFile == "" {
+		rulesFile = rules.DefaultStorePath
+	}
+	shared.Rules, err = rules.NewStore(rulesFile, shared.Config.Rules)
+	if err != nil {
+		log.Fatal("Error loading rules:", err)
+	}
+
+	shared.LinkedAccounts, err = linkedResolvers(shared.Config.LinkedAccounts)
+	if err != nil {
+		log.Fatal("Error loading linkedAccounts:", err)
+	}
+
+	shared.SubstrateClients, err = substrateClients(shared.Config.SubstrateNetworks, shared.Config.EVMnetworks, shared.Limits().RPCBatchSize)
+	if err != nil {
+		log.Fatal("Error loading substrateNetworks:", err)
+	}
+
 	shared.Clients = w3client.SetupClients(shared.Config.EVMnetworks)
+	useMulticall(shared.Clients, shared.Config.Multicall3)
 	defer w3client.CloseClients(shared.Clients)
 
+	router := newRouter()
+
+	if err := router.Run(shared.Config.Port); err != nil {
+		log.Fatal("Error loading configuration:", err)
+	}
+}
+
+// useMulticall wraps the clients of the networks in multicall3, which maps a
+// network to its Multicall3 address, or to "" for the canonical one.
+func useMulticall(clients map[string]shared.RPCClient, networks map[string]string) {
+	for network, address := range networks {
+		client, ok := clients[network]
+		if !ok {
+			log.Printf("Network %s in multicall3 is not configured", network)
+			continue
+		}
+		contract := multicall.Address
+		if address != "" {
+			if !common.IsHexAddress(address) {
+				log.Fatalf("multicall3 address %q of %s is not an address", address, network)
+			}
+			contract = common.HexToAddress(address)
+		}
+		clients[network] = multicall.New(client, network, contract)
+	}
+}
+
+// linkedResolvers returns the resolvers of every network in linkedAccounts.
+// TRN networks without an entry resolve FuturePasses.
+func linkedResolvers(configs map[string][]linked.Config) (map[string][]linked.LinkedAccountResolver, error) {
+	resolvers := make(map[string][]linked.LinkedAccountResolver)
+	for _, network := range trnNetworks {
+		if _, ok := configs[network]; !ok {
+			resolvers[network] = []linked.LinkedAccountResolver{trn.NewFuturePassResolver(0)}
+		}
+	}
+	for network, list := range configs {
+		for _, config := range list {
+			resolver, err := linked.New(config)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", network, err)
+			}
+			resolvers[network] = append(resolvers[network], resolver)
+		}
+	}
+	return resolvers, nil
+}
+
+// newRouter registers every route on a new gin engine.
+func newRouter() *gin.Engine {
 	router := gin.Default()
+	if err := router.SetTrustedProxies(shared.Config.AllowList.TrustedProxies); err != nil {
+		log.Println("Error setting trusted proxies:", err)
+	}
+	router.Use(allowIPs(shared.AllowList))
+	auth := newAuthenticator(shared.Config.SIWE)
 
-	router.POST("/api/:network/:standard/:amount/:contract", func(c *gin.Context) {
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	router.GET("/auth/nonce", auth.handleNonce)
+
+	router.POST("/auth/verify", func(c *gin.Context) {
+		auth.handleVerify(c, shared.Clients)
+	})
+
+	gated := router.Group("/", auth.session())
+
+	gated.POST("/api/:network/:standard/:amount/:contract", func(c *gin.Context) {
 		handleDynamicEndpoint(c, shared.Clients)
 	})
 
-	if err := router.Run(shared.Config.Port); err != nil {
-		log.Fatal("Error loading configuration:", err)
+	gated.POST("/api/:network/:standard/:amount", func(c *gin.Context) {
+		handleDynamicEndpoint(c, shared.Clients)
+	})
+
+	registerTRNRoutes(gated, shared.Clients)
+
+	gated.POST("/api/rule", func(c *gin.Context) {
+		handleRuleEndpoint(c, shared.Clients)
+	})
+
+	gated.POST("/api/rule/:expr", func(c *gin.Context) {
+		handleRuleEndpoint(c, shared.Clients)
+	})
+
+	gated.POST("/rules/:name", func(c *gin.Context) {
+		handleNamedRule(c, shared.Rules, shared.Clients)
+	})
+
+	registerAdminRoutes(router, shared.Config.AdminToken, shared.Rules, shared.Clients)
+
+	return router
+}
+
+func handleDynamicEndpoint(c *gin.Context, clients map[string]shared.RPCClient) {
+	rule := rules.NewCheck(c.Param("network"), c.Param("standard"), c.Param("amount"), c.Param("contract"))
+	if client, ok := shared.SubstrateClients[rule.Network]; ok {
+		handleSubstrateCheck(c, client, rule)
+		return
 	}
+	handleCheck(c, clients, rule)
 }
 
-func handleDynamicEndpoint(c *gin.Context, clients map[string]*w3.Client) {
-	network := c.Param("network")
-	standard := c.Param("standard")
-	amountStr := c.Param("amount")
-	contract := c.Param("contract")
+// handleCheck evaluates a single check for the wallets of the request.
+func handleCheck(c *gin.Context, clients map[string]shared.RPCClient, rule *rules.Rule) {
+	if err := validateCheck(rule, clients); err != nil {
+		c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
-	shared.ClientMutex.Lock()
-	client, exists := clients[network]
-	shared.ClientMutex.Unlock()
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid network"})
+	var req WalletRequest
+
+	if bindWallets(c, &req, &req) {
+		validateOwnership(c, clients, rule, req)
+	}
+}
+
+// handleRuleEndpoint evaluates a rule expression given either in the body or,
+// encoded, in the expr path segment.
+func handleRuleEndpoint(c *gin.Context, clients map[string]shared.RPCClient) {
+	var req RuleRequest
+
+	if !bindWallets(c, &req, &req.WalletRequest) {
 		return
 	}
 
-	amount, ok := utils.StrToBigInt(amountStr)
-	log.Println(&amount)
-	if !ok {
-		erc1155Format := utils.IsValidERC1155Format(amountStr)
-		if !erc1155Format {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+	if expr := c.Param("expr"); expr != "" {
+		rule, err := rules.Decode(expr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		req.Rule = rule
 	}
 
-	isValidStandard := false
+	if err := req.Rule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, check := range req.Rule.Checks() {
+		if err := validateCheck(check, clients); err != nil {
+			c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	validateOwnership(c, clients, req.Rule, req.WalletRequest)
+}
+
+// validateCheck checks the network, standard, amount and contract of a
+// single check.
+func validateCheck(check *rules.Rule, clients map[string]shared.RPCClient) error {
+	if check.Asset != nil {
+		return validateAsset(check.Asset, clients)
+	}
+
+	shared.ClientMutex.Lock()
+	_, exists := clients[check.Network]
+	shared.ClientMutex.Unlock()
+	if !exists {
+		return errors.New(errInvalidNetwork)
+	}
+
+	checker, ok := standard.Lookup(check.Standard)
+	if !ok || !standardEnabled(check.Standard) {
+		return errors.New(errInvalidStandard)
+	}
+
+	if _, contractless := checker.(standard.Contractless); !contractless && !shared.AllowList.AllowsContract(check.Network, check.Contract) {
+		return fmt.Errorf("%w: %s on %s", allowlist.ErrContractNotAllowed, check.Contract, check.Network)
+	}
+
+	if _, err := checker.NewCheck(check.Network, check.Contract, check.Amount); errors.Is(err, standard.ErrMissingContract) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", errInvalidAmount, err)
+	}
+
+	return nil
+}
+
+// standardEnabled reports whether a registered standard is enabled by
+// validStandards. Any name or alias in validStandards enables all names of
+// the standard, and an empty validStandards enables every standard.
+func standardEnabled(name string) bool {
+	if len(shared.Config.ValidStandards) == 0 {
+		return true
+	}
 	for _, s := range shared.Config.ValidStandards {
-		if standard == s {
-			isValidStandard = true
-			break
+		if standard.Same(name, s) {
+			return true
 		}
 	}
+	return false
+}
 
-	if !isValidStandard {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad API call: Invalid 'standard'"})
-		return
+// checkOptions are the per-request options of validateOwnership.
+type checkOptions struct {
+	verbose   bool
+	block     *snapshot.Spec
+	aggregate string
+}
+
+// requestOptions reads the options of validateOwnership from the query
+// string and the Accept header.
+func requestOptions(c *gin.Context) (checkOptions, error) {
+	var opts checkOptions
+	switch c.Query("verbose") {
+	case "1", "true":
+		opts.verbose = true
+	}
+	if strings.Contains(c.GetHeader("Accept"), verboseMediaType) {
+		opts.verbose = true
 	}
 
-	var req WalletRequest
+	switch opts.aggregate = c.DefaultQuery("aggregate", aggregateAny); opts.aggregate {
+	case aggregateAny, aggregateSum:
+	default:
+		return opts, errors.New(errInvalidAggregate)
+	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var err error
+	opts.block, err = snapshot.ParseSpec(c.Query("block"), c.Query("timestamp"))
+	return opts, err
+}
+
+// networkResult holds the addresses checked on a network and the block the
+// balances were fetched at.
+type networkResult struct {
+	sources map[common.Address]string
+	linked  []linked.Account
+	scopes  map[common.Address][]linked.Scope
+	block   *snapshot.Block
+}
+
+// isUnreachable reports whether a network failed to answer, as opposed to
+// a call that failed or an invalid snapshot.
+func isUnreachable(err error) bool {
+	var callErrs w3.CallErrors
+	return !errors.As(err, &callErrs) && !errors.Is(err, snapshot.ErrBeforeGenesis) && !errors.Is(err, snapshot.ErrBlockNotFound) && !errors.Is(err, standard.ErrUnsupported)
+}
+
+// nodeFailed reports whether the node refused a call, as on a timeout or a
+// rate limit, rather than the call reverting or returning unexpected data.
+func nodeFailed(callErrs w3.CallErrors) bool {
+	for _, callErr := range callErrs {
+		var rpcErr rpc.Error
+		if errors.As(callErr, &rpcErr) && !revert.Is(callErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// observeCheck records the outcome of a check, or of every network of an
+// asset.
+func observeCheck(check *rules.Rule, outcome string, duration time.Duration) {
+	if check.Asset == nil {
+		metrics.ObserveCheck(check.Network, check.Standard, outcome, duration)
 		return
 	}
+	for network := range check.Asset.Contracts {
+		metrics.ObserveCheck(network, check.Asset.Standard, outcome, duration)
+	}
+}
 
-	if req.Wallet != "" || len(req.Wallets) > 0 {
-		validateOwnership(c, network, client, contract, req, amountStr, standard)
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": shared.ErrInvalidRequest})
+// add appends the addresses that are not checked yet to addresses, so no
+// balance is counted twice, and records their source.
+func (r *networkResult) add(addresses []string, added []string, source string) []string {
+	for _, address := range added {
+		if _, exists := r.sources[w3.A(address)]; exists {
+			continue
+		}
+		r.sources[w3.A(address)] = source
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// link records the scope of a linked account and reports whether it changes
+// what the account counts for. Accounts count in full once they are checked
+// without a scope, such as wallets of the request.
+func (r *networkResult) link(account linked.Account) bool {
+	_, checked := r.sources[account.Address]
+	scopes, scoped := r.scopes[account.Address]
+	switch {
+	case checked && !scoped:
+		return false
+	case account.Scope == nil:
+		delete(r.scopes, account.Address)
+	default:
+		if r.scopes == nil {
+			r.scopes = make(map[common.Address][]linked.Scope)
+		}
+		r.scopes[account.Address] = append(scopes, *account.Scope)
 	}
+	return true
 }
 
-func validateOwnership(c *gin.Context, network string, client *w3.Client, contractAddress string, wr WalletRequest, amount string, contractStandard string) {
+// validateOwnership evaluates the rule for the wallets of the request. The
+// calls of all checks on the same network are sent in a single batch.
+func validateOwnership(c *gin.Context, clients map[string]shared.RPCClient, rule *rules.Rule, wr WalletRequest) {
+	start := time.Now()
+	opts, err := requestOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var addresses []string
-	var callRequests []w3types.Caller
-	var success bool
-	var erc20decimals *uint8
-	var fetchBalances []*big.Int
-	var erc1155TokenAmounts []*big.Int
-	var amountBigInt *big.Int
-	var err error
-	decimalMultiplier := new(big.Int).SetInt64(1)
 	if wr.Wallet != "" {
 		addresses = append(addresses, wr.Wallet)
 	}
@@ -120,87 +449,330 @@ func validateOwnership(c *gin.Context, network string, client *w3.Client, contra
 		addresses = append(addresses, wr.Wallets...)
 	}
 
-	// Support for AA operating EOAs later, right now, only FuturePass is supported on TRN.
-	switch network {
-	case "trn", "porcini":
-		addresses = trn.AddFuturePasses(addresses, *client)
+	checks := rule.Checks()
+	checksByNetwork := make(map[string][]*ownershipCheck)
+	results := make(map[*rules.Rule]leafCheck)
+	// Networks with checks that need them, as opposed to networks of assets
+	// only, which may be unreachable.
+	required := make(map[string]bool)
+	unreachable := make(map[string]string)
+	for _, check := range checks {
+		if check.Asset != nil {
+			ac, err := newAssetCheck(check, unreachable)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			for _, oc := range ac.deployments {
+				checksByNetwork[oc.rule.Network] = append(checksByNetwork[oc.rule.Network], oc)
+			}
+			results[check] = ac
+			continue
+		}
+
+		oc, err := newOwnershipCheck(check, opts.aggregate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		checksByNetwork[check.Network] = append(checksByNetwork[check.Network], oc)
+		results[check] = oc
+		required[check.Network] = true
 	}
 
-	funcBalanceOf := w3.MustNewFunc("balanceOf(address)", "uint256")
-	funcDecimals := w3.MustNewFunc("decimals()", "uint8")
+	var wg sync.WaitGroup
+	var resultMutex sync.Mutex
+	var callErr error
+	networkResults := make(map[string]*networkResult)
+	ctx := shared.Limits().WithCallBudget(c.Request.Context())
+	if shared.Metadata != nil {
+		ctx = shared.Metadata.WithBudget(ctx)
+	}
+	for network, checks := range checksByNetwork {
+		shared.ClientMutex.Lock()
+		client := clients[network]
+		shared.ClientMutex.Unlock()
+
+		wg.Add(1)
+		go func(network string, client shared.RPCClient, checks []*ownershipCheck) {
+			defer wg.Done()
+			result, err := fetchBalances(ctx, network, client, addresses, checks, opts)
+			resultMutex.Lock()
+			defer resultMutex.Unlock()
+			if err != nil && !required[network] && isUnreachable(err) {
+				log.Printf("Network %s is unreachable: %v", network, err)
+				unreachable[network] = err.Error()
+				return
+			}
+			if err != nil {
+				callErr = err
+				return
+			}
+			networkResults[network] = result
+		}(network, client, checks)
+	}
+	wg.Wait()
 
-	switch contractStandard {
-	case "erc20", "token":
-		fetchBalances = make([]*big.Int, len(addresses))
-		callRequests = append(callRequests, eth.CallFunc(w3.A(contractAddress), funcDecimals).Returns(&erc20decimals))
-		for i, address := range addresses {
-			callRequests = append(callRequests, eth.CallFunc(w3.A(contractAddress), funcBalanceOf, w3.A(address)).Returns(&fetchBalances[i]))
+	if callErr != nil {
+		for _, check := range checks {
+			observeCheck(check, metrics.OutcomeError, time.Since(start))
 		}
-
-	case "nft", "erc721":
-		fetchBalances = make([]*big.Int, len(addresses))
-		for i, address := range addresses {
-			callRequests = append(callRequests, eth.CallFunc(w3.A(contractAddress), funcBalanceOf, w3.A(address)).Returns(&fetchBalances[i]))
+	}
+	if errors.Is(callErr, snapshot.ErrBeforeGenesis) || errors.Is(callErr, snapshot.ErrBlockNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": callErr.Error()})
+		return
+	}
+	if errors.Is(callErr, standard.ErrUnsupported) || errors.Is(callErr, limits.ErrTooManyCalls) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": callErr.Error()})
+		return
+	}
+	if errors.Is(callErr, linked.ErrResolutionFailed) {
+		log.Println("Linked account error:", callErr)
+		c.JSON(http.StatusBadGateway, gin.H{"error": callErr.Error()})
+		return
+	}
+	if callErr != nil {
+		if callErrs, ok := callErr.(w3.CallErrors); ok {
+			log.Println("w3 error:", callErrs)
+			status := http.StatusInternalServerError
+			if nodeFailed(callErrs) {
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, gin.H{"w3 error": callErrs})
+		} else {
+			log.Println("Other Error:", callErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": callErr.Error()})
 		}
-	case "sft", "erc1155":
-		var erc1155TokenIds []*big.Int
-		var erc1155AddressList []common.Address
-		var erc1155IDList []*big.Int
-		funcBalanceOfBatchSFT := w3.MustNewFunc("balanceOfBatch(address[],uint256[])", "uint256[]")
-		erc1155TokenIds, erc1155TokenAmounts, err = erc1155.ParseERC1155(amount)
-		erc1155AddressList, erc1155IDList, erc1155TokenAmounts = erc1155.GenerateCombinations(addresses, erc1155TokenIds, erc1155TokenAmounts)
+		return
+	}
 
+	var evalErr error
+	success := rule.Evaluate(func(check *rules.Rule) bool {
+		met, _, err := results[check].met()
 		if err != nil {
-			log.Println(err)
+			evalErr = err
 		}
-		fetchBalances = make([]*big.Int, len(addresses)*len(erc1155TokenIds))
-		callRequests = append(callRequests, eth.CallFunc(w3.A(contractAddress), funcBalanceOfBatchSFT, erc1155AddressList, erc1155IDList).Returns(&fetchBalances))
+		return met
+	})
+	if evalErr != nil {
+		for _, check := range checks {
+			observeCheck(check, metrics.OutcomeError, time.Since(start))
+		}
+		if errors.Is(evalErr, errNetworkUnreachable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": evalErr.Error(), "unreachable": unreachable})
+			return
+		}
+		if errors.Is(evalErr, units.ErrTooManyDecimals) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": evalErr.Error()})
+			return
+		}
+		if errors.Is(evalErr, standard.ErrUnsupported) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": evalErr.Error()})
+			return
+		}
+		log.Println("Other Error:", evalErr)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": evalErr.Error()})
+		return
 	}
 
-	err = client.Call(callRequests...)
-	if err != nil {
-		if callErr, ok := err.(w3.CallErrors); ok {
-			log.Println("w3 error:", callErr)
-			log.Println("Other Error:", err)
-			c.JSON(500, gin.H{"w3 error": err.(w3.CallErrors)})
+	for _, check := range checks {
+		outcome := metrics.OutcomeFail
+		if met, _, _ := results[check].met(); met {
+			outcome = metrics.OutcomeSuccess
+		}
+		observeCheck(check, outcome, time.Since(start))
+	}
 
-		} else {
+	response := gin.H{
+		"success": success,
+	}
+	if len(unreachable) > 0 {
+		response["unreachable"] = unreachable
+	}
+	linkedAccounts := make(map[string][]linked.Account)
+	for network, result := range networkResults {
+		if len(result.linked) > 0 {
+			linkedAccounts[network] = result.linked
+		}
+	}
+	if len(linkedAccounts) > 0 {
+		response["linked"] = linkedAccounts
+	}
+	if opts.block != nil {
+		blocks := make(map[string]*snapshot.Block)
+		for network, result := range networkResults {
+			blocks[network] = result.block
+		}
+		response["blocks"] = blocks
+	}
+
+	if !opts.verbose {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	reports := make([]CheckReport, 0, len(checks))
+	for _, check := range checks {
+		report, err := results[check].report(networkResults)
+		if err != nil {
 			log.Println("Other Error:", err)
-			c.JSON(500, gin.H{"error": err})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
+		reports = append(reports, report)
 	}
 
-	for i, balance := range fetchBalances {
+	response["checks"] = reports
+	c.JSON(http.StatusOK, response)
+}
 
-		switch contractStandard {
-		case "erc20", "token":
-			decimalMultiplier = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*erc20decimals)), nil)
-			amountBigInt, _ = utils.StrToBigInt(amount)
+// fetchBalances sends the calls of every check on a network in one batch. The
+// calls are pinned to the requested block, or in verbose mode to the latest
+// block so it can be reported.
+func fetchBalances(ctx context.Context, network string, client shared.RPCClient, addresses []string, checks []*ownershipCheck, opts checkOptions) (*networkResult, error) {
+	result := &networkResult{sources: make(map[common.Address]string)}
+	addresses = result.add(nil, addresses, sourceWallet)
 
-		case "erc1155", "sft":
-			amountBigInt = new(big.Int).Set(erc1155TokenAmounts[i])
+	var blockNumber *big.Int
+	if opts.block != nil || opts.verbose {
+		block, err := snapshot.Resolve(ctx, client, opts.block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", network, err)
+		}
+		result.block = block
+		blockNumber = new(big.Int).SetUint64(block.Number)
+	}
 
-		default:
-			amountBigInt, _ = utils.StrToBigInt(amount)
+	if len(shared.LinkedAccounts[network]) > 0 {
+		owners := make([]common.Address, len(addresses))
+		for i, address := range addresses {
+			owners[i] = w3.A(address)
+		}
+		for _, resolver := range shared.LinkedAccounts[network] {
+			accounts, err := resolver.Resolve(ctx, client, owners, blockNumber)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %w", linked.ErrResolutionFailed, network, resolver.Source(), err)
+			}
+			metrics.LinkedAccountsResolved.WithLabelValues(network, resolver.Source()).Add(float64(len(accounts)))
+			if resolver.Source() == trn.SourceFuturePass {
+				metrics.FuturePassesResolved.WithLabelValues(network).Add(float64(len(accounts)))
+			}
+			for _, account := range accounts {
+				if !result.link(account) {
+					continue
+				}
+				addresses = result.add(addresses, []string{account.Address.Hex()}, account.Source)
+				result.linked = append(result.linked, account)
+			}
+		}
+		for _, oc := range checks {
+			oc.scopes = result.scopes
 		}
+	}
 
-		adjustedAmount := new(big.Int).Set(amountBigInt)
-		adjustedAmount.Mul(adjustedAmount, decimalMultiplier)
+	var callRequests []w3types.Caller
+	offsets := make([]int, len(checks))
+	for i, oc := range checks {
+		offsets[i] = len(callRequests)
+		callRequests = append(callRequests, oc.calls(addresses, blockNumber)...)
+	}
+	if err := allowFailures(sendCalls(ctx, client, callRequests), checks, offsets); err != nil {
+		return nil, err
+	}
 
-		if balance.Cmp(adjustedAmount) >= 0 {
-			success = true
-			log.Printf("balance met in element - %d", i)
-			break
+	// Staged checks make further calls from the results of the last batch,
+	// which are again sent in one batch per stage.
+	for stage := 1; ; stage++ {
+		callRequests = callRequests[:0]
+		for i, oc := range checks {
+			offsets[i] = len(callRequests)
+			staged, ok := oc.check.(standard.Staged)
+			if !ok {
+				continue
+			}
+			calls, err := staged.Next(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", network, oc.rule.Standard, err)
+			}
+			callRequests = append(callRequests, calls...)
+		}
+		if len(callRequests) == 0 {
+			return result, nil
+		}
+		if stage > maxStages {
+			return nil, fmt.Errorf("%s: checks need more than %d stages of calls", network, maxStages)
 		}
+		if err := allowFailures(sendCalls(ctx, client, callRequests), checks, offsets); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sendCalls sends the calls in JSON-RPC batches of rpcBatchSize calls, up to
+// rpcConcurrent batches at a time, and spends them from the call budget of
+// the request. Failed calls are reported as one w3.CallErrors indexed like
+// calls.
+func sendCalls(ctx context.Context, client shared.RPCClient, calls []w3types.Caller) error {
+	if err := limits.SpendCalls(ctx, len(calls)); err != nil {
+		return fmt.Errorf("%w: at most %d calls per request", err, shared.Limits().MaxCalls)
 	}
 
-	if success {
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-		})
-	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"success": false,
-		})
+	config := shared.Limits()
+	callErrs := make(w3.CallErrors, len(calls))
+	var failed bool
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, config.RPCConcurrent)
+	for start := 0; start < len(calls); start += config.RPCBatchSize {
+		end := min(start+config.RPCBatchSize, len(calls))
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := client.CallCtx(ctx, calls[start:end]...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var batchErrs w3.CallErrors
+			switch {
+			case errors.As(err, &batchErrs):
+				copy(callErrs[start:end], batchErrs)
+				failed = true
+			case err != nil && firstErr == nil:
+				firstErr = err
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if failed {
+		return callErrs
+	}
+	return nil
+}
+
+// allowFailures drops the reverts of calls that are allowed to revert, see
+// standard.Reverting. offsets holds the index of the first call of every
+// check.
+func allowFailures(err error, checks []*ownershipCheck, offsets []int) error {
+	var callErrs w3.CallErrors
+	if !errors.As(err, &callErrs) {
+		return err
+	}
+	for i, callErr := range callErrs {
+		if callErr == nil {
+			continue
+		}
+		c := sort.Search(len(offsets), func(j int) bool { return offsets[j] > i }) - 1
+		reverting, ok := checks[c].check.(standard.Reverting)
+		if !ok || !reverting.AllowFailure(i-offsets[c]) || !revert.Is(callErr) {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,599 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/metadata"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/lmittmann/w3"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	walletA  = "0x000000000000000000000000000000000000000A"
+	walletB  = "0x000000000000000000000000000000000000000B"
+	token    = "0x00000000000000000000000000000000000000C0"
+	nft      = "0x00000000000000000000000000000000000000C1"
+	sft      = "0x00000000000000000000000000000000000000C2"
+	unlisted = "0x00000000000000000000000000000000000000C3"
+)
+
+// Blocks of a mockChain are mockBlockTime seconds apart.
+const (
+	mockGenesisTime = 1700000000
+	mockBlockTime   = 12
+)
+
+type rpcMessage struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type callArgs struct {
+	To    common.Address `json:"to"`
+	Input hexutil.Bytes  `json:"data"`
+}
+
+// contractFunc handles an eth_call to a contract and returns the ABI encoded
+// result or a revert.
+type contractFunc func(input []byte, block string) ([]byte, error)
+
+// mockChain is a fake JSON-RPC node. Contract calls are dispatched on the
+// called address and the 4-byte selector of the input.
+type mockChain struct {
+	mu          sync.Mutex
+	blockNumber uint64
+	contracts   map[common.Address]map[string]contractFunc
+	methods     map[string]func(params []json.RawMessage) (any, error)
+	requests    int
+	calls       int
+	multicalls  int
+	lastBlock   string
+}
+
+func newMockChain() *mockChain {
+	return &mockChain{
+		blockNumber: 100,
+		contracts:   make(map[common.Address]map[string]contractFunc),
+		methods:     make(map[string]func(params []json.RawMessage) (any, error)),
+	}
+}
+
+// handle registers fn for calls of the function signature on contract.
+func (m *mockChain) handle(contract string, signature string, fn contractFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	addr := common.HexToAddress(contract)
+	if m.contracts[addr] == nil {
+		m.contracts[addr] = make(map[string]contractFunc)
+	}
+	selector := hex.EncodeToString(w3.MustNewFunc(signature, "").Selector[:])
+	m.contracts[addr][selector] = fn
+}
+
+func (m *mockChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	m.requests++
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var batch []rpcMessage
+		_ = json.Unmarshal(body, &batch)
+		responses := make([]map[string]any, len(batch))
+		for i, msg := range batch {
+			responses[i] = m.respond(msg)
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var msg rpcMessage
+	_ = json.Unmarshal(body, &msg)
+	_ = json.NewEncoder(w).Encode(m.respond(msg))
+}
+
+func (m *mockChain) respond(msg rpcMessage) map[string]any {
+	response := map[string]any{"jsonrpc": "2.0", "id": msg.ID}
+	result, err := m.dispatch(msg)
+	var nodeErr rpcError
+	switch {
+	case errors.As(err, &nodeErr):
+		response["error"] = map[string]any{"code": nodeErr.code, "message": nodeErr.message}
+		return response
+	case err != nil:
+		response["error"] = map[string]any{"code": 3, "message": "execution reverted: " + err.Error()}
+		return response
+	}
+	response["result"] = result
+	return response
+}
+
+func (m *mockChain) dispatch(msg rpcMessage) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if fn, ok := m.methods[msg.Method]; ok {
+		return fn(msg.Params)
+	}
+
+	switch msg.Method {
+	case "eth_blockNumber":
+		return hexutil.EncodeUint64(m.blockNumber), nil
+	case "eth_getBlockByNumber":
+		var tag string
+		_ = json.Unmarshal(msg.Params[0], &tag)
+		number := m.blockNumber
+		switch tag {
+		case "latest", "safe":
+		case "finalized":
+			number -= 2
+		case "earliest":
+			number = 0
+		default:
+			n, err := hexutil.DecodeUint64(tag)
+			if err != nil || n > m.blockNumber {
+				return nil, nil
+			}
+			number = n
+		}
+		return map[string]any{
+			"number":    hexutil.EncodeUint64(number),
+			"hash":      common.BigToHash(new(big.Int).SetUint64(number + 0xb10c)),
+			"timestamp": hexutil.EncodeUint64(mockGenesisTime + number*mockBlockTime),
+		}, nil
+	case "eth_call":
+		m.calls++
+		var args callArgs
+		var block string
+		_ = json.Unmarshal(msg.Params[0], &args)
+		if len(msg.Params) > 1 {
+			_ = json.Unmarshal(msg.Params[1], &block)
+		}
+		m.lastBlock = block
+		// Like a real chain, calls to an address without code succeed with
+		// empty output.
+		if m.contracts[args.To] == nil {
+			return hexutil.Bytes{}, nil
+		}
+		if len(args.Input) < 4 {
+			return nil, errRevert
+		}
+		fn, ok := m.contracts[args.To][hex.EncodeToString(args.Input[:4])]
+		if !ok {
+			return nil, errRevert
+		}
+		out, err := fn(args.Input[4:], block)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Bytes(out), nil
+	}
+	return nil, errRevert
+}
+
+var errRevert = errorString("revert")
+
+type errorString string
+
+func (e errorString) Error() string { return string(e) }
+
+// rpcError is a failure of the node rather than of the call, such as a rate
+// limit.
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string { return e.message }
+
+var errRateLimited = rpcError{-32005, "rate limit exceeded"}
+
+// pack ABI encodes values of the given solidity types.
+func pack(t *testing.T, types []string, values ...any) []byte {
+	var args abi.Arguments
+	for _, typ := range types {
+		abiType, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, abi.Argument{Type: abiType})
+	}
+	out, err := args.Pack(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// unpack ABI decodes the arguments of a call.
+func unpack(t *testing.T, types []string, input []byte) []any {
+	var args abi.Arguments
+	for _, typ := range types {
+		abiType, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, abi.Argument{Type: abiType})
+	}
+	values, err := args.Unpack(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+// balances registers an ERC-20/ERC-721 style balanceOf and decimals on
+// contract.
+func (m *mockChain) balances(t *testing.T, contract string, decimals uint8, balances map[string]int64) {
+	m.handle(contract, "decimals()", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"uint8"}, decimals), nil
+	})
+	m.handle(contract, "balanceOf(address)", func(input []byte, block string) ([]byte, error) {
+		owner := unpack(t, []string{"address"}, input)[0].(common.Address)
+		balance := big.NewInt(0)
+		for address, value := range balances {
+			if common.HexToAddress(address) == owner {
+				balance = new(big.Int).Mul(big.NewInt(value), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+			}
+		}
+		return pack(t, []string{"uint256"}, balance), nil
+	})
+}
+
+// balances1155 registers balanceOfBatch on contract with balances per
+// address and token ID.
+func (m *mockChain) balances1155(t *testing.T, contract string, balances map[string]map[int64]int64) {
+	m.handle(contract, "balanceOfBatch(address[],uint256[])", func(input []byte, block string) ([]byte, error) {
+		values := unpack(t, []string{"address[]", "uint256[]"}, input)
+		owners := values[0].([]common.Address)
+		ids := values[1].([]*big.Int)
+		result := make([]*big.Int, len(owners))
+		for i, owner := range owners {
+			result[i] = big.NewInt(0)
+			for address, byID := range balances {
+				if common.HexToAddress(address) == owner {
+					result[i] = big.NewInt(byID[ids[i].Int64()])
+				}
+			}
+		}
+		return pack(t, []string{"uint256[]"}, result), nil
+	})
+}
+
+// nativeBalances registers eth_getBalance with balances in wei per address.
+func (m *mockChain) nativeBalances(balances map[string]*big.Int) {
+	m.methods["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+		var owner common.Address
+		if err := json.Unmarshal(params[0], &owner); err != nil {
+			return nil, err
+		}
+		balance := big.NewInt(0)
+		for address, value := range balances {
+			if common.HexToAddress(address) == owner {
+				balance = value
+			}
+		}
+		return hexutil.EncodeBig(balance), nil
+	}
+}
+
+// setupMockNetworks installs a configuration and clients for the given mock
+// chains and returns the router.
+func setupMockNetworks(t *testing.T, chains map[string]*mockChain) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	networks := make(map[string][]string)
+	clients := make(map[string]shared.RPCClient)
+	for network, chain := range chains {
+		srv := httptest.NewServer(chain)
+		t.Cleanup(srv.Close)
+		client, err := w3.Dial(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		networks[network] = []string{srv.URL}
+		clients[network] = client
+	}
+
+	shared.Config = &shared.Configuration{
+		EVMnetworks:    networks,
+		ValidStandards: []string{"erc20", "token", "erc721", "nft", "sft", "erc1155", "native"},
+	}
+	shared.Clients = clients
+	shared.AllowList = nil
+	shared.Metadata, _ = metadata.New(metadata.Config{AllowPrivate: true})
+	shared.LinkedAccounts, _ = linkedResolvers(nil)
+	shared.SubstrateClients = nil
+	return newRouter()
+}
+
+// post sends a JSON body to the router and decodes the JSON response.
+func post(t *testing.T, router *gin.Engine, path string, body any, header ...string) (int, map[string]any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+// get sends a GET request and returns the status and the decoded response.
+func get(t *testing.T, router *gin.Engine, path string) (int, map[string]any) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+// pathTest is a request to the dynamic endpoint with the expected status and,
+// for 200, the expected success.
+type pathTest struct {
+	path    string
+	wallets []string
+	status  int
+	success bool
+}
+
+func testPaths(t *testing.T, router *gin.Engine, tests []pathTest) {
+	t.Helper()
+	for _, test := range tests {
+		status, response := post(t, router, test.path, WalletRequest{Wallets: test.wallets})
+		assert.Equal(t, test.status, status, "Status should match for %s", test.path)
+		if status == http.StatusOK {
+			assert.Equal(t, test.success, response["success"], "Success should match for %s", test.path)
+		}
+	}
+}
+
+// setupHoldings serves eth with token, nft and sft balances of walletA and
+// walletB.
+func setupHoldings(t *testing.T) *gin.Engine {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 500, walletB: 1500})
+	eth.balances(t, nft, 0, map[string]int64{walletB: 2})
+	eth.balances1155(t, sft, map[string]map[int64]int64{walletA: {5: 1, 9: 3}})
+	return setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+}
+
+func TestDynamicEndpoint(t *testing.T) {
+	testPaths(t, setupHoldings(t), []pathTest{
+		{"/api/eth/erc20/1000/" + token, []string{walletA}, http.StatusOK, false},
+		{"/api/eth/erc20/1000/" + token, []string{walletA, walletB}, http.StatusOK, true},
+		{"/api/eth/nft/2/" + nft, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/nft/3/" + nft, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/erc1155/1_2&9_3/" + sft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc1155/1_2&9_4/" + sft, []string{walletA}, http.StatusOK, false},
+		{"/api/sol/erc20/1/" + token, []string{walletA}, http.StatusBadRequest, false},
+		{"/api/eth/erc404/1/" + token, []string{walletA}, http.StatusBadRequest, false},
+		{"/api/eth/erc20/abc/" + token, []string{walletA}, http.StatusBadRequest, false},
+		{"/api/eth/erc20/1/" + unlisted, []string{walletA}, http.StatusInternalServerError, false},
+	})
+}
+
+func TestComparisons(t *testing.T) {
+	testPaths(t, setupHoldings(t), []pathTest{
+		{"/api/eth/nft/between:1:4/" + nft, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/nft/lte:0/" + nft, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/nft/lte:0/" + nft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/nft/eq:2/" + nft, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/nft/gt:2/" + nft, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/nft/between:4:1/" + nft, []string{walletB}, http.StatusBadRequest, false},
+		{"/api/eth/erc20/lt:1000/" + token, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc20/between:1000:2000/" + token, []string{walletA}, http.StatusOK, false},
+		{"/api/eth/erc1155/9_lt:3/" + sft, []string{walletA}, http.StatusOK, false},
+		{"/api/eth/erc1155/9_between:2:3/" + sft, []string{walletA}, http.StatusOK, true},
+	})
+}
+
+func TestERC1155Selectors(t *testing.T) {
+	testPaths(t, setupHoldings(t), []pathTest{
+		{"/api/eth/erc1155/4-6/" + sft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc1155/1-4_2&8-10_3/" + sft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc1155/1-4&0x9_4/" + sft, []string{walletA}, http.StatusOK, false},
+		{"/api/eth/erc1155/0x9_3/" + sft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc1155/5-3/" + sft, []string{walletA}, http.StatusBadRequest, false},
+		{"/api/eth/erc1155/1-3&5-7/" + sft, []string{walletA}, http.StatusOK, true},
+	})
+}
+
+func TestDecimalAmounts(t *testing.T) {
+	testPaths(t, setupHoldings(t), []pathTest{
+		{"/api/eth/erc20/1499.5/" + token, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/erc20/1500.000000000000000001/" + token, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/1.5e3/" + token, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/erc20/1.501e3/" + token, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/wei:1500000000000000000000/" + token, []string{walletB}, http.StatusOK, true},
+		{"/api/eth/erc20/wei:1500000000000000000001/" + token, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/1e-19/" + token, []string{walletB}, http.StatusBadRequest, false},
+	})
+}
+
+func TestRuleEndpoint(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 1000})
+	eth.balances(t, nft, 0, map[string]int64{walletA: 1})
+	arb := newMockChain()
+	arb.balances(t, token, 6, map[string]int64{walletB: 10})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth, "arb": arb})
+
+	rule := `{"and": [
+		{"network": "eth", "standard": "erc721", "amount": "1", "contract": "` + nft + `"},
+		{"network": "eth", "standard": "erc20", "amount": "1000", "contract": "` + token + `"},
+		{"not": {"network": "arb", "standard": "erc20", "amount": "100", "contract": "` + token + `"}}
+	]}`
+
+	// Test case 1: Rule in the body, checks on eth are sent in one batch
+	status, response := post(t, router, "/api/rule", map[string]any{"rule": json.RawMessage(rule), "wallets": []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status, "Status should be 200")
+	assert.Equal(t, true, response["success"], "Rule should pass")
+	assert.Equal(t, 1, eth.requests, "eth checks should be batched in one request")
+
+	// Test case 2: Encoded rule in the path
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(rule))
+	status, response = post(t, router, "/api/rule/"+encoded, WalletRequest{Wallet: walletB})
+	assert.Equal(t, http.StatusOK, status, "Status should be 200")
+	assert.Equal(t, false, response["success"], "Rule should fail")
+
+	// Test case 3: Invalid check in the rule
+	invalid := `{"or": [{"network": "sol", "standard": "erc20", "amount": "1", "contract": "` + token + `"}]}`
+	status, _ = post(t, router, "/api/rule", map[string]any{"rule": json.RawMessage(invalid), "wallet": walletA})
+	assert.Equal(t, http.StatusBadRequest, status, "Status should be 400")
+}
+
+func TestSnapshotBlock(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 1000})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+	path := "/api/eth/erc20/1000/" + token
+
+	tests := []struct {
+		query     string
+		status    int
+		number    float64
+		callBlock string
+	}{
+		{"?block=50", http.StatusOK, 50, "0x32"},
+		{"?block=0x40", http.StatusOK, 64, "0x40"},
+		{"?block=finalized", http.StatusOK, 98, "0x62"},
+		{fmt.Sprintf("?timestamp=%d", mockGenesisTime+40*mockBlockTime+5), http.StatusOK, 40, "0x28"},
+		{"?timestamp=1", http.StatusBadRequest, 0, ""},
+		{"?block=500", http.StatusBadRequest, 0, ""},
+		{"?block=soon", http.StatusBadRequest, 0, ""},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, path+test.query, WalletRequest{Wallet: walletA})
+		assert.Equal(t, test.status, status, "Status should match for %s", test.query)
+		if status != http.StatusOK {
+			continue
+		}
+		block := response["blocks"].(map[string]any)["eth"].(map[string]any)
+		assert.Equal(t, test.number, block["number"], "Resolved block should match for %s", test.query)
+		assert.NotEmpty(t, block["hash"], "Block hash should be echoed")
+		assert.Equal(t, test.callBlock, eth.lastBlock, "Calls should run at the resolved block for %s", test.query)
+	}
+}
+
+func TestValidStandards(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, nft, 0, map[string]int64{walletA: 1})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+	shared.Config.ValidStandards = []string{"nft"}
+
+	status, response := post(t, router, "/api/eth/erc721/1/"+nft, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status, "Alias in validStandards should enable the standard")
+	assert.Equal(t, true, response["success"])
+
+	status, _ = post(t, router, "/api/eth/erc20/1/"+token, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusBadRequest, status, "Standard missing from validStandards should be rejected")
+
+	status, _ = post(t, router, "/api/eth/erc1155/1_x/"+sft, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusBadRequest, status, "Invalid amount should be rejected")
+}
+
+func TestNativeBalance(t *testing.T) {
+	eth := newMockChain()
+	eth.nativeBalances(map[string]*big.Int{walletA: big.NewInt(5e17)})
+	trn := newMockChain()
+	trn.nativeBalances(map[string]*big.Int{walletB: big.NewInt(2_000_000)})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth, "xrp": trn})
+	shared.Config.NativeDecimals = map[string]uint8{"xrp": 6}
+
+	tests := []struct {
+		path    string
+		status  int
+		success bool
+	}{
+		{"/api/eth/native/0.5", http.StatusOK, true},
+		{"/api/eth/native/0.51", http.StatusOK, false},
+		{"/api/eth/native/0.5/" + token, http.StatusOK, true},
+		{"/api/xrp/native/2", http.StatusOK, true},
+		{"/api/xrp/native/2.000001", http.StatusOK, false},
+		{"/api/xrp/native/0.0000001", http.StatusBadRequest, false},
+		{"/api/eth/native/abc", http.StatusBadRequest, false},
+		{"/api/eth/erc20/1", http.StatusBadRequest, false},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, test.path, WalletRequest{Wallets: []string{walletA, walletB}})
+		assert.Equal(t, test.status, status, "Status should match for %s", test.path)
+		if status == http.StatusOK {
+			assert.Equal(t, test.success, response["success"], "Success should match for %s", test.path)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletB: 1000})
+	eth.balances1155(t, sft, map[string]map[int64]int64{walletB: {6: 1}})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+	shared.Config.Limits = limits.Config{MaxTokenIDs: 6, MaxWallets: 2, MaxCalls: 8, BatchSize: 4, RPCBatchSize: 2}
+
+	testPaths(t, router, []pathTest{
+		{"/api/eth/erc20/1000/" + token, []string{walletA, walletB}, http.StatusOK, true},
+		{"/api/eth/erc20/1000/" + token, []string{walletA, walletB, walletA}, http.StatusOK, true},
+		{"/api/eth/erc20/1000/" + token, []string{walletA, walletB, unlisted}, http.StatusRequestEntityTooLarge, false},
+		{"/api/eth/erc1155/1-6/" + sft, []string{walletA, walletB}, http.StatusOK, true},
+		{"/api/eth/erc1155/1-7/" + sft, []string{walletA}, http.StatusUnprocessableEntity, false},
+		{"/api/eth/erc1155/0-999999999/" + sft, []string{walletA}, http.StatusUnprocessableEntity, false},
+		{"/api/eth/erc721/ids:1-7/" + nft, []string{walletA}, http.StatusUnprocessableEntity, false},
+	})
+
+	// 2 wallets x 6 token IDs are split into 3 balanceOfBatch calls of 4
+	// pairs, sent in 2 JSON-RPC batches.
+	calls, requests := eth.calls, eth.requests
+	status, response := post(t, router, "/api/eth/erc1155/1-6/"+sft+"?verbose=true", WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 3, eth.calls-calls, "Should chunk balanceOfBatch")
+	assert.Equal(t, 3, eth.requests-requests, "Should send the block number and 2 batches")
+	entries := response["checks"].([]any)[0].(map[string]any)["entries"].([]any)
+	assert.Len(t, entries, 12, "Should report every pair")
+	assert.Equal(t, true, entries[11].(map[string]any)["met"], "Chunks should be joined in order")
+
+	// 2 checks of 6 calls exceed the 8 calls of a request.
+	rule := `{"or": [
+		{"network": "eth", "standard": "erc721", "amount": "ids:1-6", "contract": "` + nft + `"},
+		{"network": "eth", "standard": "erc721", "amount": "ids:11-16", "contract": "` + nft + `"}
+	]}`
+	status, _ = post(t, router, "/api/rule", map[string]any{"rule": json.RawMessage(rule), "wallet": walletA})
+	assert.Equal(t, http.StatusUnprocessableEntity, status, "Should refuse requests over the call limit")
+}
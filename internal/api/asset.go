@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/FN00EU/vulcan-one/internal/units"
+)
+
+var errNetworkUnreachable = errors.New("asset cannot be evaluated, networks are unreachable")
+
+// leafCheck is a leaf of a rule, either a single check or an asset.
+type leafCheck interface {
+	met() (bool, int, error)
+	report(networkResults map[string]*networkResult) (CheckReport, error)
+}
+
+// assetCheck sums the balances of an asset on all of its networks. The
+// deployments are fetched with the amount 0, as the amount is compared with
+// the total only.
+type assetCheck struct {
+	rule        *rules.Rule
+	deployments []*ownershipCheck
+	unreachable map[string]string
+}
+
+func newAssetCheck(rule *rules.Rule, unreachable map[string]string) (*assetCheck, error) {
+	ac := &assetCheck{rule: rule, unreachable: unreachable}
+	for _, deployment := range rule.Asset.Deployments() {
+		deployment.Amount = "0"
+		oc, err := newOwnershipCheck(deployment, aggregateSum)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", deployment.Network, err)
+		}
+		ac.deployments = append(ac.deployments, oc)
+	}
+	return ac, nil
+}
+
+// validateAsset checks every deployment of an asset and its amount, which
+// must be a decimal number of tokens. Only fungible standards can be assets,
+// as the amounts of other standards select token IDs.
+func validateAsset(asset *rules.Asset, clients map[string]shared.RPCClient) error {
+	if !standard.Same(asset.Standard, "erc20") && !standard.Same(asset.Standard, "native") {
+		return fmt.Errorf("%s: assets must be erc20 or native, got %q", errInvalidStandard, asset.Standard)
+	}
+	for _, deployment := range asset.Deployments() {
+		if err := validateCheck(deployment, clients); err != nil {
+			return fmt.Errorf("%s: %w", deployment.Network, err)
+		}
+	}
+	condition, err := compare.Parse(asset.Amount)
+	if err == nil {
+		_, err = condition.Convert(func(amount string) (*big.Int, error) {
+			_, err := units.Parse(amount)
+			return new(big.Int), err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", errInvalidAmount, err)
+	}
+	return nil
+}
+
+// networkTotal is the balance of an asset on one network, summed over all
+// addresses, in the token's smallest unit on that network.
+type networkTotal struct {
+	network  string
+	balance  *big.Int
+	decimals uint8
+}
+
+// totals returns the balance on every reachable network and the largest
+// decimals of the deployments.
+func (ac *assetCheck) totals() ([]networkTotal, uint8, error) {
+	var totals []networkTotal
+	var maxDecimals uint8
+	for _, oc := range ac.deployments {
+		if _, unreachable := ac.unreachable[oc.rule.Network]; unreachable {
+			continue
+		}
+		results, err := oc.results()
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", oc.rule.Network, err)
+		}
+
+		total := networkTotal{network: oc.rule.Network, balance: new(big.Int)}
+		if scaled, ok := oc.check.(standard.Scaled); ok {
+			total.decimals = scaled.Decimals()
+		}
+		for _, result := range results {
+			if result.Balance != nil {
+				total.balance.Add(total.balance, result.Balance)
+			}
+		}
+		if total.decimals > maxDecimals {
+			maxDecimals = total.decimals
+		}
+		totals = append(totals, total)
+	}
+	return totals, maxDecimals, nil
+}
+
+// total returns the sum of the balances on the reachable networks and the
+// threshold, both scaled to the largest decimals of the deployments.
+func (ac *assetCheck) total() (*big.Int, compare.Threshold, []networkTotal, uint8, error) {
+	totals, decimals, err := ac.totals()
+	if err != nil {
+		return nil, compare.Threshold{}, nil, 0, err
+	}
+
+	condition, err := compare.Parse(ac.rule.Asset.Amount)
+	if err != nil {
+		return nil, compare.Threshold{}, nil, 0, err
+	}
+	threshold, err := condition.Convert(func(amount string) (*big.Int, error) {
+		return units.ParseDecimal(amount, decimals)
+	})
+	if err != nil {
+		return nil, compare.Threshold{}, nil, 0, err
+	}
+
+	sum := new(big.Int)
+	for i := range totals {
+		totals[i].balance = scale(totals[i].balance, decimals-totals[i].decimals)
+		sum.Add(sum, totals[i].balance)
+	}
+	return sum, threshold, totals, decimals, nil
+}
+
+func scale(balance *big.Int, digits uint8) *big.Int {
+	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	return new(big.Int).Mul(balance, multiplier)
+}
+
+// unreachableNetworks returns the networks of the asset that could not be
+// queried.
+func (ac *assetCheck) unreachableNetworks() []string {
+	var networks []string
+	for network := range ac.rule.Asset.Contracts {
+		if _, unreachable := ac.unreachable[network]; unreachable {
+			networks = append(networks, network)
+		}
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// met compares the total with the amount. With unreachable networks the sum
+// is only a lower bound, so the asset can be met but not failed.
+func (ac *assetCheck) met() (bool, int, error) {
+	sum, threshold, _, _, err := ac.total()
+	if err != nil {
+		return false, -1, err
+	}
+
+	met := threshold.Met(sum)
+	if networks := ac.unreachableNetworks(); len(networks) > 0 {
+		lowerBound := threshold.Op == compare.Gte || threshold.Op == compare.Gt
+		if !met || !lowerBound {
+			return false, -1, fmt.Errorf("%w: %s", errNetworkUnreachable, strings.Join(networks, ", "))
+		}
+	}
+	if met {
+		return true, 0, nil
+	}
+	return false, -1, nil
+}
+
+func (ac *assetCheck) report(map[string]*networkResult) (CheckReport, error) {
+	report := CheckReport{
+		Standard:    ac.rule.Asset.Standard,
+		Amount:      ac.rule.Asset.Amount,
+		Contracts:   ac.rule.Asset.Contracts,
+		Unreachable: ac.unreachableNetworks(),
+	}
+
+	sum, threshold, totals, decimals, err := ac.total()
+	if err != nil {
+		return report, err
+	}
+	report.Total = sum.String()
+	report.Decimals = &decimals
+	report.Success, _, err = ac.met()
+	if err != nil && !errors.Is(err, errNetworkUnreachable) {
+		return report, err
+	}
+
+	report.Entries = make([]EntryReport, 0, len(totals))
+	for _, total := range totals {
+		report.Entries = append(report.Entries, EntryReport{
+			Network:   total.network,
+			Source:    aggregateSum,
+			Balance:   total.balance.String(),
+			Threshold: threshold.String(),
+			Met:       threshold.Met(total.balance),
+		})
+	}
+	return report, nil
+}
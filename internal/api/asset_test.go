@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/lmittmann/w3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetRule(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 600})
+	arb := newMockChain()
+	arb.balances(t, token, 6, map[string]int64{walletB: 500})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth, "arb": arb})
+
+	// A network whose node does not answer.
+	down := httptest.NewServer(newMockChain())
+	down.Close()
+	client, err := w3.Dial(down.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared.Config.EVMnetworks["bsc"] = []string{down.URL}
+	shared.Clients["bsc"] = client
+
+	assetOf := func(standard, amount string, networks ...string) json.RawMessage {
+		contracts := make(map[string]string)
+		for _, network := range networks {
+			contracts[network] = token
+		}
+		rule, _ := json.Marshal(map[string]any{"asset": map[string]any{"standard": standard, "amount": amount, "contracts": contracts}})
+		return rule
+	}
+	asset := func(amount string, networks ...string) json.RawMessage {
+		return assetOf("erc20", amount, networks...)
+	}
+
+	tests := []struct {
+		name    string
+		rule    json.RawMessage
+		status  int
+		success bool
+	}{
+		{"sum across networks", asset("1000", "eth", "arb"), http.StatusOK, true},
+		{"fractional amount", asset("1100.5", "eth", "arb"), http.StatusOK, false},
+		{"operator", asset("between:1000:1100", "eth", "arb"), http.StatusOK, true},
+		{"single network", asset("1000", "eth"), http.StatusOK, false},
+		{"met despite unreachable network", asset("1000", "eth", "arb", "bsc"), http.StatusOK, true},
+		{"unmet with unreachable network", asset("2000", "eth", "arb", "bsc"), http.StatusServiceUnavailable, false},
+		{"upper bound with unreachable network", asset("lt:2000", "eth", "arb", "bsc"), http.StatusServiceUnavailable, false},
+		{"unknown network", asset("1000", "eth", "sol"), http.StatusBadRequest, false},
+		{"invalid amount", asset("ten", "eth", "arb"), http.StatusBadRequest, false},
+		{"no contracts", asset("1000"), http.StatusBadRequest, false},
+		{"erc1155 asset", assetOf("erc1155", "1", "eth", "arb"), http.StatusBadRequest, false},
+		{"erc721 asset", assetOf("erc721", "ids:1", "eth", "arb"), http.StatusBadRequest, false},
+		{"token alias", assetOf("token", "1000", "eth", "arb"), http.StatusOK, true},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, "/api/rule", map[string]any{"rule": test.rule, "wallets": []string{walletA, walletB}})
+		assert.Equal(t, test.status, status, "Status should match for %s", test.name)
+		if status == http.StatusOK {
+			assert.Equal(t, test.success, response["success"], "Success should match for %s", test.name)
+		}
+		if status == http.StatusServiceUnavailable {
+			assert.Contains(t, response["unreachable"], "bsc", "Unreachable networks should be reported for %s", test.name)
+		}
+	}
+
+	// Balances are reported per network in the largest decimals.
+	_, response := post(t, router, "/api/rule?verbose=true", map[string]any{"rule": asset("1000", "eth", "arb", "bsc"), "wallets": []string{walletA, walletB}})
+	report := response["checks"].([]any)[0].(map[string]any)
+	assert.Equal(t, "1100000000000000000000", report["total"])
+	assert.Equal(t, float64(18), report["decimals"])
+	assert.Equal(t, []any{"bsc"}, report["unreachable"])
+	assert.Len(t, report["entries"], 2, "Should report one entry per reachable network")
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/siwe"
+	"github.com/gin-gonic/gin"
+	"github.com/lmittmann/w3/module/eth"
+)
+
+const (
+	sessionKey         = "siweSession"
+	errSessionRequired = "Sign-In With Ethereum session token required"
+	errWalletNotProven = "Wallet is not proven by the session token"
+	errSIWEDisabled    = "Sign-In With Ethereum is disabled, set siwe.domain"
+)
+
+// VerifyRequest is the body of the SIWE verify endpoint.
+type VerifyRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// authenticator issues SIWE nonces and session tokens and restricts the
+// wallets of gating requests to those proven by a session token.
+type authenticator struct {
+	nonces   *siwe.Nonces
+	secret   []byte
+	domain   string
+	required bool
+	ttl      time.Duration
+
+	chainMutex sync.Mutex
+	chainIDs   map[string]uint64
+}
+
+func newAuthenticator(config shared.SIWEConfig) *authenticator {
+	a := &authenticator{
+		nonces:   siwe.NewNonces(),
+		secret:   []byte(config.SessionSecret),
+		domain:   config.Domain,
+		required: config.SessionRequired(),
+		ttl:      siwe.SessionTTL,
+		chainIDs: make(map[string]uint64),
+	}
+	if config.SessionTTL > 0 {
+		a.ttl = time.Duration(config.SessionTTL) * time.Second
+	}
+	if a.domain == "" {
+		log.Println("No SIWE domain configured, Sign-In With Ethereum is disabled and any wallet can be checked")
+	} else if !a.required {
+		log.Println("siwe.required is false, any wallet can be checked without a session token")
+	}
+	if len(a.secret) == 0 {
+		log.Println("No SIWE session secret configured, session tokens will not survive a restart")
+		a.secret = make([]byte, 32)
+		if _, err := rand.Read(a.secret); err != nil {
+			log.Fatal("Error generating session secret:", err)
+		}
+	}
+	return a
+}
+
+// session parses the session token of gating requests, if any, and rejects
+// requests without one when SIWE is required.
+func (a *authenticator) session() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			if a.required {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errSessionRequired})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		session, err := siwe.ParseToken(a.secret, token, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(sessionKey, session)
+		c.Next()
+	}
+}
+
+func (a *authenticator) handleNonce(c *gin.Context) {
+	if a.domain == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSIWEDisabled})
+		return
+	}
+	nonce, err := a.nonces.Issue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"nonce": nonce})
+}
+
+// handleVerify checks a signed SIWE message and returns a session token. A
+// valid token in the Authorization header is extended with the new wallet.
+func (a *authenticator) handleVerify(c *gin.Context, clients map[string]shared.RPCClient) {
+	if a.domain == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSIWEDisabled})
+		return
+	}
+
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	msg, err := siwe.ParseMessage(req.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := msg.Validate(a.domain, now); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !a.nonces.Consume(msg.Nonce, now) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": siwe.ErrUnknownNonce.Error()})
+		return
+	}
+
+	client := a.clientForChain(c.Request.Context(), clients, msg.ChainID)
+	if err := siwe.VerifySignature(c.Request.Context(), client, msg, req.Message, req.Signature); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := &siwe.Session{}
+	if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		if existing, err := siwe.ParseToken(a.secret, token, now); err == nil {
+			session = existing
+		}
+	}
+	session.Add(msg.Address)
+	session.Expires = now.Add(a.ttl).Unix()
+
+	token, err := siwe.SignToken(a.secret, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"addresses": session.Addresses,
+		"expires":   session.Expires,
+	})
+}
+
+// clientForChain returns the client of the configured network with the given
+// chain ID, used to verify EIP-1271 signatures, or nil if there is none.
+func (a *authenticator) clientForChain(ctx context.Context, clients map[string]shared.RPCClient, chainID uint64) shared.RPCClient {
+	shared.ClientMutex.Lock()
+	networks := make(map[string]shared.RPCClient, len(clients))
+	for network, client := range clients {
+		networks[network] = client
+	}
+	shared.ClientMutex.Unlock()
+
+	a.chainMutex.Lock()
+	defer a.chainMutex.Unlock()
+	for network, client := range networks {
+		id, ok := a.chainIDs[network]
+		if !ok {
+			if err := client.CallCtx(ctx, eth.ChainID().Returns(&id)); err != nil {
+				log.Printf("Error fetching chain ID of %s: %v", network, err)
+				continue
+			}
+			a.chainIDs[network] = id
+		}
+		if id == chainID {
+			return client
+		}
+	}
+	return nil
+}
+
+// bindWallets binds the JSON body into obj, whose wallets are wr. With a SIWE
+// session the wallets are restricted to the proven ones, and an empty body
+// checks every proven wallet. It writes the error response and returns false
+// if the request cannot be checked.
+func bindWallets(c *gin.Context, obj any, wr *WalletRequest) bool {
+	value, hasSession := c.Get(sessionKey)
+
+	if err := c.ShouldBindJSON(obj); err != nil && !(hasSession && errors.Is(err, io.EOF)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if hasSession {
+		session := value.(*siwe.Session)
+		if wr.Wallet == "" && len(wr.Wallets) == 0 {
+			for _, address := range session.Addresses {
+				wr.Wallets = append(wr.Wallets, address.Hex())
+			}
+		}
+
+		var proven []string
+		for _, address := range append([]string{wr.Wallet}, wr.Wallets...) {
+			if address != "" && session.Contains(address) {
+				proven = append(proven, address)
+			}
+		}
+		if len(proven) == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": errWalletNotProven})
+			return false
+		}
+		wr.Wallet, wr.Wallets = "", proven
+	}
+
+	if wr.Wallet == "" && len(wr.Wallets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": shared.ErrInvalidRequest})
+		return false
+	}
+	if err := shared.Limits().Wallets(countWallets(wr)); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// countWallets returns the number of distinct wallets of the request.
+func countWallets(wr *WalletRequest) int {
+	seen := make(map[string]bool)
+	for _, address := range append([]string{wr.Wallet}, wr.Wallets...) {
+		if address != "" {
+			seen[strings.ToLower(address)] = true
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// siweMessage returns an EIP-4361 message for address on chain 1.
+func siweMessage(domain string, address common.Address, nonce string) string {
+	now := time.Now().UTC()
+	return fmt.Sprintf("%s wants you to sign in with your Ethereum account:\n%s\n\nURI: https://%s\nVersion: 1\nChain ID: 1\nNonce: %s\nIssued At: %s\nExpiration Time: %s",
+		domain, address.Hex(), domain, nonce, now.Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339))
+}
+
+func TestSIWE(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	holder := crypto.PubkeyToAddress(key.PublicKey)
+	safe := common.HexToAddress("0x00000000000000000000000000000000000005AF")
+
+	eth := newMockChain()
+	eth.balances(t, nft, 0, map[string]int64{holder.Hex(): 1, walletA: 1})
+	eth.methods["eth_chainId"] = func(params []json.RawMessage) (any, error) { return "0x1", nil }
+	eth.handle(safe.Hex(), "isValidSignature(bytes32,bytes)", func(input []byte, block string) ([]byte, error) {
+		signature := unpack(t, []string{"bytes32", "bytes"}, input)[1].([]byte)
+		if string(signature) != "approved" {
+			return pack(t, []string{"bytes4"}, [4]byte{}), nil
+		}
+		return pack(t, []string{"bytes4"}, [4]byte{0x16, 0x26, 0xba, 0x7e}), nil
+	})
+	setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+	shared.Config.SIWE = shared.SIWEConfig{Domain: "vulcan.test", SessionSecret: "secret"}
+	router := newRouter()
+	path := "/api/eth/nft/1/" + nft
+
+	nonce := func() string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/nonce", nil))
+		var response map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return response["nonce"]
+	}
+
+	// Test case 1: Session token required
+	status, _ := post(t, router, path, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusUnauthorized, status, "Request without session should be rejected")
+
+	// Test case 2: EOA signature
+	message := siweMessage("vulcan.test", holder, nonce())
+	sig, _ := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	status, response := post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode(sig)})
+	assert.Equal(t, http.StatusOK, status, "Signature should be verified")
+	token := response["token"].(string)
+
+	status, _ = post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode(sig)})
+	assert.Equal(t, http.StatusUnauthorized, status, "Nonce should not be reusable")
+
+	status, response = post(t, router, path, map[string]any{}, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, status, "Proven wallet should be checked")
+	assert.Equal(t, true, response["success"], "Proven wallet holds the NFT")
+
+	status, _ = post(t, router, path, WalletRequest{Wallet: walletA}, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusForbidden, status, "Unproven wallet should be rejected")
+
+	// Test case 3: EIP-1271 smart-contract wallet, added to the existing session
+	message = siweMessage("vulcan.test", safe, nonce())
+	status, response = post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode([]byte("approved"))}, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, status, "Contract signature should be verified")
+	assert.Len(t, response["addresses"], 2, "Session should hold both wallets")
+
+	message = siweMessage("vulcan.test", safe, nonce())
+	status, _ = post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode([]byte("rejected"))})
+	assert.Equal(t, http.StatusUnauthorized, status, "Rejected contract signature should fail")
+
+	// Test case 4: Wrong domain
+	message = siweMessage("evil.test", holder, nonce())
+	sig, _ = crypto.Sign(accounts.TextHash([]byte(message)), key)
+	status, _ = post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode(sig)})
+	assert.Equal(t, http.StatusUnauthorized, status, "Message for another domain should be rejected")
+
+	// Test case 5: The request host is not the domain
+	message = siweMessage("evil.test", holder, nonce())
+	sig, _ = crypto.Sign(accounts.TextHash([]byte(message)), key)
+	request := httptest.NewRequest(http.MethodPost, "/auth/verify", strings.NewReader(fmt.Sprintf(`{"message": %q, "signature": %q}`, message, hexutil.Encode(sig))))
+	request.Host = "evil.test"
+	request.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Message for the request host should be rejected")
+
+	// Test case 6: Sessions can be made optional explicitly
+	optional := false
+	shared.Config.SIWE = shared.SIWEConfig{Domain: "vulcan.test", Required: &optional, SessionSecret: "secret"}
+	router = newRouter()
+	status, _ = post(t, router, path, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status, "Request without session should be accepted when not required")
+	status, _ = post(t, router, path, WalletRequest{Wallet: walletA}, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusForbidden, status, "Session tokens should still be enforced")
+
+	// Test case 7: Without a domain SIWE is disabled
+	shared.Config.SIWE = shared.SIWEConfig{SessionSecret: "secret"}
+	router = newRouter()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/nonce", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "Nonces should not be issued without a domain")
+	status, _ = post(t, router, "/auth/verify", VerifyRequest{Message: message, Signature: hexutil.Encode(sig)})
+	assert.Equal(t, http.StatusServiceUnavailable, status, "Messages should not be verified without a domain")
+}
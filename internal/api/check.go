@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/w3types"
+
+	// Token standards register themselves with the standard package.
+	_ "github.com/FN00EU/vulcan-one/internal/erc1155"
+	_ "github.com/FN00EU/vulcan-one/internal/erc20"
+	_ "github.com/FN00EU/vulcan-one/internal/erc721"
+	_ "github.com/FN00EU/vulcan-one/internal/native"
+)
+
+// CheckReport is the verbose result of a single check of a rule. For an
+// asset, Contracts lists the contract on every network, Total is the sum of
+// the balances on the reachable networks and the entries are the balances
+// per network, all scaled to Decimals.
+type CheckReport struct {
+	Network     string            `json:"network,omitempty"`
+	Standard    string            `json:"standard"`
+	Amount      string            `json:"amount"`
+	Contract    string            `json:"contract,omitempty"`
+	Contracts   map[string]string `json:"contracts,omitempty"`
+	BlockNumber uint64            `json:"blockNumber,omitempty"`
+	Success     bool              `json:"success"`
+	MetBy       *int              `json:"metBy,omitempty"`
+	Total       string            `json:"total,omitempty"`
+	Decimals    *uint8            `json:"decimals,omitempty"`
+	Unreachable []string          `json:"unreachable,omitempty"`
+	Entries     []EntryReport     `json:"entries"`
+}
+
+// EntryReport is one compared balance of a check. Balances and thresholds are
+// decimal strings in the token's smallest unit.
+type EntryReport struct {
+	Network   string `json:"network,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Source    string `json:"source"`
+	TokenID   string `json:"tokenId,omitempty"`
+	Balance   string `json:"balance"`
+	Threshold string `json:"threshold"`
+	Met       bool   `json:"met"`
+}
+
+// ownershipCheck holds the calls and the fetched results of a single balance
+// check of a rule.
+type ownershipCheck struct {
+	rule        *rules.Rule
+	blockNumber *big.Int
+	check       standard.Check
+	aggregate   string
+	// scopes limit the balances of linked accounts that count only for
+	// some contracts, tokens or amounts.
+	scopes map[common.Address][]linked.Scope
+}
+
+func newOwnershipCheck(rule *rules.Rule, aggregate string) (*ownershipCheck, error) {
+	checker, ok := standard.Lookup(rule.Standard)
+	if !ok {
+		return nil, fmt.Errorf(shared.ErrIncorrectStandard, rule.Standard)
+	}
+	check, err := checker.NewCheck(rule.Network, rule.Contract, rule.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &ownershipCheck{rule: rule, check: check, aggregate: aggregate}, nil
+}
+
+// results returns the compared balances of the check, summed over all
+// addresses in aggregateSum mode, and combined if the check is met by a
+// combination of balances.
+func (oc *ownershipCheck) results() ([]standard.Result, error) {
+	results, err := oc.check.Results()
+	if err != nil {
+		return nil, err
+	}
+	results = oc.constrain(results)
+	if oc.aggregate == aggregateSum {
+		results = standard.Sum(results)
+	}
+	if combiner, ok := oc.check.(standard.Combiner); ok {
+		results = combiner.Combine(results)
+	}
+	return results, nil
+}
+
+// constrain limits the balances of scoped linked accounts to what their
+// scopes allow in the contract of the check. Results of selective checks
+// without a token ID may count tokens other than the scoped ones, so token
+// scopes allow nothing for them.
+func (oc *ownershipCheck) constrain(results []standard.Result) []standard.Result {
+	if len(oc.scopes) == 0 {
+		return results
+	}
+	_, selective := oc.check.(standard.Selective)
+	contract := common.HexToAddress(oc.rule.Contract)
+	constrained := make([]standard.Result, len(results))
+	for i, result := range results {
+		constrained[i] = result
+		if scopes, ok := oc.scopes[result.Address]; ok {
+			if selective && result.TokenID == nil {
+				scopes = linked.WithoutTokenIDs(scopes)
+			}
+			constrained[i].Balance = linked.Allowance(scopes, contract, result.TokenID, result.Balance)
+			constrained[i].Met = result.Threshold.Met(constrained[i].Balance)
+		}
+	}
+	return constrained
+}
+
+// calls returns the balance calls of the check for every address at the given
+// block, or at the latest block if blockNumber is nil.
+func (oc *ownershipCheck) calls(addresses []string, blockNumber *big.Int) []w3types.Caller {
+	oc.blockNumber = blockNumber
+	parsed := make([]common.Address, len(addresses))
+	for i, address := range addresses {
+		parsed[i] = w3.A(address)
+	}
+	return oc.check.Calls(parsed, blockNumber)
+}
+
+// met reports whether any fetched balance meets the amount of the check and
+// the index of the first balance that does, or -1.
+func (oc *ownershipCheck) met() (bool, int, error) {
+	results, err := oc.results()
+	if err != nil {
+		return false, -1, err
+	}
+	for i, result := range results {
+		if result.Met {
+			log.Printf("balance met in element - %d", i)
+			return true, i, nil
+		}
+	}
+
+	return false, -1, nil
+}
+
+// report returns every compared balance of the check, with the source of
+// each address, such as "wallet" or "futurepass".
+func (oc *ownershipCheck) report(networkResults map[string]*networkResult) (CheckReport, error) {
+	var sources map[common.Address]string
+	if result := networkResults[oc.rule.Network]; result != nil {
+		sources = result.sources
+	}
+
+	report := CheckReport{
+		Network:  oc.rule.Network,
+		Standard: oc.rule.Standard,
+		Amount:   oc.rule.Amount,
+		Contract: oc.rule.Contract,
+	}
+	if oc.blockNumber != nil {
+		report.BlockNumber = oc.blockNumber.Uint64()
+	}
+
+	results, err := oc.results()
+	if err != nil {
+		return report, err
+	}
+
+	report.Entries = make([]EntryReport, 0, len(results))
+	for i, result := range results {
+		entry := EntryReport{
+			Address: result.Address.Hex(),
+			Source:  sources[result.Address],
+			Met:     result.Met,
+		}
+		if oc.aggregate == aggregateSum {
+			entry.Address, entry.Source = "", aggregateSum
+		}
+		if result.TokenID != nil {
+			entry.TokenID = result.TokenID.String()
+		}
+		if result.Balance != nil {
+			entry.Balance = result.Balance.String()
+		}
+		entry.Threshold = result.Threshold.String()
+		if entry.Met && report.MetBy == nil {
+			metBy := i
+			report.MetBy = &metBy
+			report.Success = true
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return report, nil
+}
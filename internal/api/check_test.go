@@ -0,0 +1,243 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerboseReport(t *testing.T) {
+	eth := newMockChain()
+	eth.balances1155(t, sft, map[string]map[int64]int64{walletA: {9: 3}})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+
+	status, response := post(t, router, "/api/eth/erc1155/1_2&9_3/"+sft+"?verbose=true", WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status, "Status should be 200")
+	checks := response["checks"].([]any)
+	assert.Len(t, checks, 1, "Should report one check")
+
+	report := checks[0].(map[string]any)
+	assert.Equal(t, float64(100), report["blockNumber"], "Block number should be reported")
+	assert.Equal(t, float64(1), report["metBy"], "Second entry should meet the rule")
+	entries := report["entries"].([]any)
+	assert.Len(t, entries, 4, "Should report every address and token ID")
+	entry := entries[1].(map[string]any)
+	assert.Equal(t, walletA, entry["address"], "Address should match")
+	assert.Equal(t, "wallet", entry["source"], "Source should match")
+	assert.Equal(t, "9", entry["tokenId"], "Token ID should match")
+	assert.Equal(t, "3", entry["balance"], "Balance should match")
+	assert.Equal(t, "3", entry["threshold"], "Threshold should match")
+
+	// Test case 2: Accept header
+	_, response = post(t, router, "/api/eth/erc1155/9_3/"+sft, WalletRequest{Wallet: walletA}, "Accept", verboseMediaType)
+	assert.Contains(t, response, "checks", "Accept header should enable verbose mode")
+}
+
+func TestAggregateSum(t *testing.T) {
+	eth := newMockChain()
+	eth.balances(t, token, 18, map[string]int64{walletA: 600, walletB: 500})
+	eth.balances1155(t, sft, map[string]map[int64]int64{walletA: {1: 1, 2: 1}, walletB: {1: 1}})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+
+	testPaths(t, router, []pathTest{
+		{"/api/eth/erc20/1000/" + token, []string{walletA, walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/1000/" + token + "?aggregate=any", []string{walletA, walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/1000/" + token + "?aggregate=sum", []string{walletA, walletB}, http.StatusOK, true},
+		{"/api/eth/erc20/1000/" + token + "?aggregate=sum", []string{walletA, walletA}, http.StatusOK, false},
+		{"/api/eth/erc1155/1_2/" + sft + "?aggregate=sum", []string{walletA, walletB}, http.StatusOK, true},
+		{"/api/eth/erc1155/2_2/" + sft + "?aggregate=sum", []string{walletA, walletB}, http.StatusOK, false},
+		{"/api/eth/erc20/1000/" + token + "?aggregate=max", []string{walletA}, http.StatusBadRequest, false},
+	})
+
+	_, response := post(t, router, "/api/eth/erc1155/1_2&2_1/"+sft+"?aggregate=sum&verbose=true", WalletRequest{Wallets: []string{walletA, walletB}})
+	entries := response["checks"].([]any)[0].(map[string]any)["entries"].([]any)
+	assert.Len(t, entries, 2, "Should report one sum per token ID")
+	entry := entries[0].(map[string]any)
+	assert.Equal(t, "sum", entry["source"])
+	assert.Equal(t, "1", entry["tokenId"])
+	assert.Equal(t, "2", entry["balance"])
+}
+
+func TestAggregateFuturePass(t *testing.T) {
+	const futurePass = "0xFfFFFFff00000000000000000000000000000001"
+	chain := newMockChain()
+	chain.balances(t, token, 18, map[string]int64{walletA: 600, futurePass: 500})
+	chain.futurePasses(t, map[string]string{walletA: futurePass})
+	router := setupMockNetworks(t, map[string]*mockChain{"trn": chain})
+
+	status, response := post(t, router, "/api/trn/erc20/1000/"+token, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, response["success"], "No single address should hold 1000")
+
+	status, response = post(t, router, "/api/trn/erc20/1000/"+token+"?aggregate=sum", WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["success"], "Wallet and FuturePass should be summed")
+}
+
+func TestTokenIDs(t *testing.T) {
+	eth := newMockChain()
+	eth.handle(nft, "ownerOf(uint256)", func(input []byte, block string) ([]byte, error) {
+		id := unpack(t, []string{"uint256"}, input)[0].(*big.Int)
+		switch id.Int64() {
+		case 7:
+			return pack(t, []string{"address"}, common.HexToAddress(walletA)), nil
+		case 42:
+			return pack(t, []string{"address"}, common.HexToAddress(walletB)), nil
+		case 13:
+			return nil, errRateLimited
+		}
+		return nil, errorString("ERC721: invalid token ID")
+	})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+
+	tests := []struct {
+		amount  string
+		wallets []string
+		status  int
+		success bool
+	}{
+		{"ids:7", []string{walletA}, http.StatusOK, true},
+		{"ids:7", []string{walletB}, http.StatusOK, false},
+		{"ids:1,42", []string{walletA, walletB}, http.StatusOK, true},
+		{"ids:1-10", []string{walletA}, http.StatusOK, true},
+		{"ids:1-6", []string{walletA}, http.StatusOK, false},
+		{"ids:8-9,43", []string{walletA, walletB}, http.StatusOK, false},
+		{"ids:12-14", []string{walletA}, http.StatusServiceUnavailable, false},
+		{"ids:5-3", []string{walletA}, http.StatusBadRequest, false},
+		{"ids:1-100000", []string{walletA}, http.StatusUnprocessableEntity, false},
+		{"ids:x", []string{walletA}, http.StatusBadRequest, false},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, "/api/eth/erc721/"+test.amount+"/"+nft, WalletRequest{Wallets: test.wallets})
+		assert.Equal(t, test.status, status, "Status should match for %s", test.amount)
+		if status == http.StatusOK {
+			assert.Equal(t, test.success, response["success"], "Success should match for %s", test.amount)
+		}
+	}
+
+	// ownerOf is called once per token ID, not per wallet.
+	calls := eth.calls
+	post(t, router, "/api/eth/erc721/ids:1-10/"+nft, WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, 10, eth.calls-calls, "Should call ownerOf once per token ID")
+
+	_, response := post(t, router, "/api/eth/erc721/ids:6-7/"+nft+"?verbose=true", WalletRequest{Wallet: walletA})
+	entries := response["checks"].([]any)[0].(map[string]any)["entries"].([]any)
+	assert.Len(t, entries, 2, "Should report every token ID")
+	assert.Equal(t, "7", entries[1].(map[string]any)["tokenId"])
+	assert.Equal(t, true, entries[1].(map[string]any)["met"])
+}
+
+func TestTraits(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		rarity := map[string]string{"/1": "Common", "/2": "Legendary", "/3": "Common"}[r.URL.Path]
+		if rarity == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"attributes": [{"trait_type": "Rarity", "value": %q}, {"trait_type": "Level", "value": 5}]}`, rarity)
+	}))
+	defer server.Close()
+
+	// walletA owns tokens 1 and 2, walletB owns 3 and 4, which has no
+	// metadata, and whale owns more tokens than are checked for traits.
+	const whale = "0x00000000000000000000000000000000000000C4"
+	owned := map[common.Address][]int64{
+		common.HexToAddress(walletA): {1, 2},
+		common.HexToAddress(walletB): {3, 4},
+	}
+	for id := int64(1000); id < 1150; id++ {
+		owned[common.HexToAddress(whale)] = append(owned[common.HexToAddress(whale)], id)
+	}
+	eth := newMockChain()
+	eth.handle(nft, "supportsInterface(bytes4)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, true), nil
+	})
+	eth.handle(nft, "balanceOf(address)", func(input []byte, block string) ([]byte, error) {
+		owner := unpack(t, []string{"address"}, input)[0].(common.Address)
+		return pack(t, []string{"uint256"}, big.NewInt(int64(len(owned[owner])))), nil
+	})
+	eth.handle(nft, "tokenOfOwnerByIndex(address,uint256)", func(input []byte, block string) ([]byte, error) {
+		values := unpack(t, []string{"address", "uint256"}, input)
+		return pack(t, []string{"uint256"}, big.NewInt(owned[values[0].(common.Address)][values[1].(*big.Int).Int64()])), nil
+	})
+	eth.handle(nft, "tokenURI(uint256)", func(input []byte, block string) ([]byte, error) {
+		id := unpack(t, []string{"uint256"}, input)[0].(*big.Int)
+		if id.Int64() == 4 {
+			return nil, errorString("URI query for nonexistent token")
+		}
+		return pack(t, []string{"string"}, fmt.Sprintf("%s/%d", server.URL, id)), nil
+	})
+	plain := newMockChain()
+	plain.balances(t, nft, 0, map[string]int64{walletA: 1})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth, "arb": plain})
+
+	testPaths(t, router, []pathTest{
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc721/traits:rarity=legendary,level=5/" + nft, []string{walletA}, http.StatusOK, true},
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{walletB}, http.StatusOK, false},
+		{"/api/eth/erc721/traits:Rarity=Common,Level=6/" + nft, []string{walletA, walletB}, http.StatusOK, false},
+		{"/api/eth/erc721/traits:Rarity/" + nft, []string{walletA}, http.StatusBadRequest, false},
+		{"/api/arb/erc721/traits:Rarity=Legendary/" + nft, []string{walletA}, http.StatusUnprocessableEntity, false},
+	})
+	assert.Equal(t, int32(3), fetches.Load(), "Metadata should be cached between requests")
+
+	// Unchecked tokens might match, so a whale is refused rather than denied.
+	testPaths(t, router, []pathTest{
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{whale}, http.StatusUnprocessableEntity, false},
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{walletA, whale}, http.StatusOK, true},
+	})
+}
+
+func TestERC1155Modes(t *testing.T) {
+	eth := newMockChain()
+	eth.balances1155(t, sft, map[string]map[int64]int64{
+		walletA: {1: 1, 2: 1, 3: 4},
+		walletB: {4: 1, 5: 2},
+	})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+
+	tests := []struct {
+		amount    string
+		wallets   []string
+		aggregate string
+		success   bool
+	}{
+		{"all:1-3", []string{walletA}, "any", true},
+		{"all:1-4", []string{walletA, walletB}, "any", false},
+		{"all:1-5", []string{walletA, walletB}, "sum", true},
+		{"all:1-3_2", []string{walletA}, "any", false},
+		{"all:1-2&3_4", []string{walletA}, "any", true},
+		{"distinct:3:1-10", []string{walletA}, "any", true},
+		{"distinct:4:1-10", []string{walletA, walletB}, "any", false},
+		{"distinct:4:1-10", []string{walletA, walletB}, "sum", true},
+		{"total:6:1-3", []string{walletA}, "any", true},
+		{"total:7:1-5", []string{walletA, walletB}, "any", false},
+		{"total:7:1-5", []string{walletA, walletB}, "sum", true},
+	}
+
+	for _, test := range tests {
+		path := "/api/eth/erc1155/" + test.amount + "/" + sft + "?aggregate=" + test.aggregate
+		status, response := post(t, router, path, WalletRequest{Wallets: test.wallets})
+		assert.Equal(t, http.StatusOK, status, "Status should match for %s", path)
+		assert.Equal(t, test.success, response["success"], "Success should match for %s", path)
+	}
+
+	// All modes are evaluated from a single balanceOfBatch.
+	calls := eth.calls
+	post(t, router, "/api/eth/erc1155/distinct:2:1-10/"+sft, WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, 1, eth.calls-calls, "Should send one balanceOfBatch")
+
+	_, response := post(t, router, "/api/eth/erc1155/total:6:1-3/"+sft+"?verbose=true", WalletRequest{Wallet: walletA})
+	entry := response["checks"].([]any)[0].(map[string]any)["entries"].([]any)[0].(map[string]any)
+	assert.Equal(t, "6", entry["balance"])
+	assert.Equal(t, "6", entry["threshold"])
+}
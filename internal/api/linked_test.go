@@ -0,0 +1,310 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkedAccounts(t *testing.T) {
+	const (
+		factory        = "0x00000000000000000000000000000000000000F0"
+		smartAccount   = "0x00000000000000000000000000000000000004a7"
+		collection     = "0x00000000000000000000000000000000000000F1"
+		implementation = "0x00000000000000000000000000000000000000F2"
+		boundAccount   = "0x0000000000000000000000000000000000006551"
+		safe           = "0x000000000000000000000000000000000000005A"
+		otherSafe      = "0x000000000000000000000000000000000000005B"
+		multisig       = "0x000000000000000000000000000000000000005C"
+		undeployed     = "0x000000000000000000000000000000000000005D"
+	)
+	chain := newMockChain()
+	chain.balances(t, token, 18, map[string]int64{smartAccount: 1, boundAccount: 10, safe: 100, otherSafe: 1000, multisig: 10000})
+	chain.handle(factory, "getAddress(address,uint256)", func(input []byte, block string) ([]byte, error) {
+		if unpack(t, []string{"address", "uint256"}, input)[0].(common.Address) == common.HexToAddress(walletA) {
+			return pack(t, []string{"address"}, common.HexToAddress(smartAccount)), nil
+		}
+		return pack(t, []string{"address"}, common.Address{}), nil
+	})
+	chain.balances(t, collection, 0, map[string]int64{walletA: 1})
+	chain.handle(collection, "tokenOfOwnerByIndex(address,uint256)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"uint256"}, big.NewInt(42)), nil
+	})
+	chain.handle(linked.RegistryERC6551.Hex(), "account(address,bytes32,uint256,address,uint256)", func(input []byte, block string) ([]byte, error) {
+		values := unpack(t, []string{"address", "bytes32", "uint256", "address", "uint256"}, input)
+		assert.Equal(t, common.HexToAddress(implementation), values[0])
+		assert.Equal(t, int64(1), values[2].(*big.Int).Int64())
+		assert.Equal(t, int64(42), values[4].(*big.Int).Int64())
+		return pack(t, []string{"address"}, common.HexToAddress(boundAccount)), nil
+	})
+	chain.handle(safe, "isOwner(address)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, unpack(t, []string{"address"}, input)[0].(common.Address) == common.HexToAddress(walletA)), nil
+	})
+	// otherSafe lists walletA in the registry but no longer as an owner.
+	chain.handle(otherSafe, "isOwner(address)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, false), nil
+	})
+	// multisig is owned by walletA but needs a second signature.
+	chain.handle(multisig, "isOwner(address)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, true), nil
+	})
+	for address, threshold := range map[string]int64{safe: 1, otherSafe: 1, multisig: 2} {
+		threshold := threshold
+		chain.handle(address, "getThreshold()", func(input []byte, block string) ([]byte, error) {
+			return pack(t, []string{"uint256"}, big.NewInt(threshold)), nil
+		})
+	}
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/owners/"+common.HexToAddress(walletA).Hex()+"/safes/" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"safes": []string{safe, otherSafe, multisig, undeployed}})
+	}))
+	t.Cleanup(registry.Close)
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": chain})
+
+	var err error
+	shared.LinkedAccounts, err = linkedResolvers(map[string][]linked.Config{"eth": {
+		{Type: linked.TypeERC4337, Factory: factory},
+		{Type: linked.TypeERC6551, Implementation: implementation, TokenContract: collection, ChainID: 1},
+		{Type: linked.TypeSafe, Registry: registry.URL},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, response := post(t, router, "/api/eth/erc20/111/"+token+"?aggregate=sum&verbose=1", WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["success"], "Linked accounts should count for walletA")
+	assert.Equal(t, []any{
+		map[string]any{"address": common.HexToAddress(smartAccount).Hex(), "owner": common.HexToAddress(walletA).Hex(), "source": "erc4337"},
+		map[string]any{"address": common.HexToAddress(boundAccount).Hex(), "owner": common.HexToAddress(walletA).Hex(), "source": "erc6551"},
+		map[string]any{"address": common.HexToAddress(safe).Hex(), "owner": common.HexToAddress(walletA).Hex(), "source": "safe"},
+	}, response["linked"].(map[string]any)["eth"])
+
+	status, response = post(t, router, "/api/eth/erc20/112/"+token+"?aggregate=sum", WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, response["success"], "Safes no longer owned or with co-signers should not count")
+
+	status, response = post(t, router, "/api/eth/erc20/1/"+token, WalletRequest{Wallet: walletB})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, response["success"])
+	assert.Nil(t, response["linked"], "walletB has no linked accounts")
+
+	// Only Safes without code are taken as not owned, not Safes the node
+	// fails to answer for.
+	chain.handle(safe, "getThreshold()", func(input []byte, block string) ([]byte, error) {
+		return nil, errRateLimited
+	})
+	status, _ = post(t, router, "/api/eth/erc20/1/"+token, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusBadGateway, status, "Safe failures should fail the resolution")
+
+	// TRN networks resolve FuturePasses unless configured otherwise.
+	resolvers, err := linkedResolvers(map[string][]linked.Config{"porcini": {}})
+	assert.NoError(t, err)
+	assert.Len(t, resolvers["trn"], 1)
+	assert.Empty(t, resolvers["porcini"])
+
+	_, err = linkedResolvers(map[string][]linked.Config{"eth": {{Type: "eoa"}}})
+	assert.ErrorIs(t, err, linked.ErrUnknownType)
+}
+
+// delegation is a Delegation of the delegate.xyz v2 registry.
+type delegation struct {
+	Kind     uint8
+	To       common.Address
+	From     common.Address
+	Rights   [32]byte
+	Contract common.Address
+	TokenId  *big.Int
+	Amount   *big.Int
+}
+
+// delegations registers getIncomingDelegations on the delegate.xyz registry
+// with the delegations to every wallet.
+func (m *mockChain) delegations(t *testing.T, delegations map[string][]delegation) {
+	getIncomingDelegations := w3.MustNewFunc("getIncomingDelegations(address)", "(uint8 kind, address to, address from, bytes32 rights, address contract, uint256 tokenId, uint256 amount)[]")
+	m.handle(linked.RegistryDelegate.Hex(), "getIncomingDelegations(address)", func(input []byte, block string) ([]byte, error) {
+		to := unpack(t, []string{"address"}, input)[0].(common.Address)
+		incoming := []delegation{}
+		for wallet, list := range delegations {
+			if common.HexToAddress(wallet) == to {
+				incoming = list
+			}
+		}
+		output, err := getIncomingDelegations.Returns.Pack(incoming)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output, nil
+	})
+}
+
+func TestDelegations(t *testing.T) {
+	const (
+		other         = "0x00000000000000000000000000000000000000C4"
+		vaultAll      = "0x00000000000000000000000000000000000000E1"
+		vaultContract = "0x00000000000000000000000000000000000000E2"
+		vaultERC20    = "0x00000000000000000000000000000000000000E3"
+		vaultToken    = "0x00000000000000000000000000000000000000E4"
+		vaultStale    = "0x00000000000000000000000000000000000000E5"
+		vault1155     = "0x00000000000000000000000000000000000000E6"
+		vaultRights   = "0x00000000000000000000000000000000000000E7"
+	)
+	delegate := func(kind uint8, vault, contract string, tokenID, amount int64) delegation {
+		return delegation{
+			Kind:     kind,
+			To:       common.HexToAddress(walletA),
+			From:     common.HexToAddress(vault),
+			Contract: common.HexToAddress(contract),
+			TokenId:  big.NewInt(tokenID),
+			Amount:   big.NewInt(amount),
+		}
+	}
+	tokens := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	erc20 := delegate(4, vaultERC20, token, 0, 0)
+	erc20.Amount.Mul(big.NewInt(100), tokens)
+	rights := delegate(1, vaultRights, "", 0, 0)
+	rights.Rights[31] = 1
+
+	chain := newMockChain()
+	chain.delegations(t, map[string][]delegation{walletA: {
+		delegate(1, vaultAll, "", 0, 0),
+		delegate(2, vaultContract, nft, 0, 0),
+		erc20,
+		delegate(3, vaultToken, nft, 7, 0),
+		delegate(3, vaultStale, nft, 8, 0),
+		delegate(5, vault1155, sft, 1, 2),
+		rights,
+	}})
+	chain.balances(t, other, 0, map[string]int64{vaultAll: 1000, vaultRights: 10000})
+	chain.balances(t, token, 18, map[string]int64{vaultContract: 500, vaultERC20: 1000})
+	chain.balances(t, nft, 0, map[string]int64{vaultContract: 3, vaultToken: 5, vaultStale: 10})
+	chain.handle(nft, "ownerOf(uint256)", func(input []byte, block string) ([]byte, error) {
+		switch unpack(t, []string{"uint256"}, input)[0].(*big.Int).Int64() {
+		case 7, 9:
+			return pack(t, []string{"address"}, common.HexToAddress(vaultToken)), nil
+		case 8:
+			return pack(t, []string{"address"}, common.HexToAddress(walletB)), nil
+		}
+		return nil, errRevert
+	})
+	chain.balances1155(t, sft, map[string]map[int64]int64{vault1155: {1: 5, 2: 5}})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": chain})
+	var err error
+	shared.LinkedAccounts, err = linkedResolvers(map[string][]linked.Config{"eth": {{Type: linked.TypeDelegate}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		success bool
+	}{
+		{"wallet delegation counts in full", "/api/eth/erc20/1000/" + other, true},
+		{"delegation with other rights is ignored", "/api/eth/erc20/1001/" + other, false},
+		{"amount delegation counts up to its amount", "/api/eth/erc20/100/" + token, true},
+		{"amount delegation counts no more than its amount", "/api/eth/erc20/101/" + token, false},
+		{"contract delegation does not count for other contracts", "/api/eth/erc20/500/" + token, false},
+		{"contract delegation counts in full", "/api/eth/erc721/3/" + nft, true},
+		{"token delegation counts as one token", "/api/eth/erc721/4/" + nft, false},
+		{"token and contract delegations add up", "/api/eth/erc721/4/" + nft + "?aggregate=sum", true},
+		{"stale token delegation is ignored", "/api/eth/erc721/5/" + nft + "?aggregate=sum", false},
+		{"delegated token", "/api/eth/erc721/ids:7/" + nft, true},
+		{"token delegated by a former owner", "/api/eth/erc721/ids:8/" + nft, false},
+		{"token owned but not delegated", "/api/eth/erc721/ids:9/" + nft, false},
+		{"erc1155 delegation counts up to its amount", "/api/eth/erc1155/1_2/" + sft, true},
+		{"erc1155 delegation counts no more than its amount", "/api/eth/erc1155/1_3/" + sft, false},
+		{"erc1155 delegation does not count for other token IDs", "/api/eth/erc1155/2_1/" + sft, false},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, test.path, WalletRequest{Wallet: walletA})
+		assert.Equal(t, http.StatusOK, status, test.name)
+		assert.Equal(t, test.success, response["success"], test.name)
+	}
+
+	_, response := post(t, router, "/api/eth/erc20/1/"+token, WalletRequest{Wallet: walletA})
+	linkedAccounts := response["linked"].(map[string]any)["eth"].([]any)
+	assert.Len(t, linkedAccounts, 5, "Stale delegations and other rights should not be listed")
+	assert.Equal(t, map[string]any{
+		"address": common.HexToAddress(vaultERC20).Hex(),
+		"owner":   common.HexToAddress(walletA).Hex(),
+		"source":  "delegate",
+		"scope":   map[string]any{"contract": common.HexToAddress(token).Hex(), "amount": erc20.Amount.String()},
+	}, linkedAccounts[2])
+
+	// A token delegation is dropped only if ownerOf reverts, not if the
+	// node fails to answer.
+	chain.handle(nft, "ownerOf(uint256)", func(input []byte, block string) ([]byte, error) {
+		return nil, errRateLimited
+	})
+	status, _ := post(t, router, "/api/eth/erc721/ids:7/"+nft, WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusBadGateway, status, "ownerOf failures should fail the resolution")
+}
+
+func TestTokenScopedTraits(t *testing.T) {
+	const vault = "0x00000000000000000000000000000000000000E4"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rarity := map[string]string{"/7": "Common", "/9": "Legendary"}[r.URL.Path]
+		if rarity == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"attributes": [{"trait_type": "Rarity", "value": %q}]}`, rarity)
+	}))
+	defer server.Close()
+
+	// The vault owns tokens 7 and 9 but delegated only 7, which is not
+	// Legendary, to walletA.
+	owned := map[common.Address][]int64{common.HexToAddress(vault): {7, 9}}
+	chain := newMockChain()
+	chain.delegations(t, map[string][]delegation{walletA: {{
+		Kind:     3,
+		To:       common.HexToAddress(walletA),
+		From:     common.HexToAddress(vault),
+		Contract: common.HexToAddress(nft),
+		TokenId:  big.NewInt(7),
+		Amount:   new(big.Int),
+	}}})
+	chain.handle(nft, "ownerOf(uint256)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"address"}, common.HexToAddress(vault)), nil
+	})
+	chain.handle(nft, "supportsInterface(bytes4)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, true), nil
+	})
+	chain.handle(nft, "balanceOf(address)", func(input []byte, block string) ([]byte, error) {
+		owner := unpack(t, []string{"address"}, input)[0].(common.Address)
+		return pack(t, []string{"uint256"}, big.NewInt(int64(len(owned[owner])))), nil
+	})
+	chain.handle(nft, "tokenOfOwnerByIndex(address,uint256)", func(input []byte, block string) ([]byte, error) {
+		values := unpack(t, []string{"address", "uint256"}, input)
+		return pack(t, []string{"uint256"}, big.NewInt(owned[values[0].(common.Address)][values[1].(*big.Int).Int64()])), nil
+	})
+	chain.handle(nft, "tokenURI(uint256)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"string"}, fmt.Sprintf("%s/%d", server.URL, unpack(t, []string{"uint256"}, input)[0].(*big.Int))), nil
+	})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": chain})
+	var err error
+	shared.LinkedAccounts, err = linkedResolvers(map[string][]linked.Config{"eth": {{Type: linked.TypeDelegate}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testPaths(t, router, []pathTest{
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{vault}, http.StatusOK, true},
+		{"/api/eth/erc721/traits:Rarity=Legendary/" + nft, []string{walletA}, http.StatusOK, false},
+		{"/api/eth/erc721/1/" + nft, []string{walletA}, http.StatusOK, true},
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/multicall"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/stretchr/testify/assert"
+)
+
+// multicall deploys Multicall3 at its canonical address. aggregate3 makes
+// every call on the mock chain and reports reverts as failed calls.
+func (m *mockChain) multicall(t *testing.T) {
+	aggregate3 := w3.MustNewFunc("aggregate3((address target, bool allowFailure, bytes callData)[] calls)", "(bool success, bytes returnData)[] returnData")
+	type call3 struct {
+		Target       common.Address
+		AllowFailure bool
+		CallData     []byte
+	}
+	type result3 struct {
+		Success    bool
+		ReturnData []byte
+	}
+	m.handle(multicall.Address.Hex(), "aggregate3((address,bool,bytes)[])", func(input []byte, block string) ([]byte, error) {
+		var calls []call3
+		selector := aggregate3.Selector
+		if err := aggregate3.DecodeArgs(append(selector[:], input...), &calls); err != nil {
+			t.Fatal(err)
+		}
+		results := make([]result3, len(calls))
+		for i, call := range calls {
+			fn, ok := m.contracts[call.Target][hex.EncodeToString(call.CallData[:4])]
+			if !ok {
+				continue
+			}
+			out, err := fn(call.CallData[4:], block)
+			results[i] = result3{Success: err == nil, ReturnData: out}
+		}
+		m.multicalls++
+		output, err := aggregate3.Returns.Pack(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output, nil
+	})
+}
+
+func TestMulticall(t *testing.T) {
+	eth := newMockChain()
+	eth.multicall(t)
+	eth.balances(t, token, 18, map[string]int64{walletA: 500, walletB: 1500})
+	eth.handle(nft, "ownerOf(uint256)", func(input []byte, block string) ([]byte, error) {
+		if unpack(t, []string{"uint256"}, input)[0].(*big.Int).Int64() == 2 {
+			return pack(t, []string{"address"}, common.HexToAddress(walletB)), nil
+		}
+		return nil, errRevert
+	})
+	eth.nativeBalances(map[string]*big.Int{walletA: big.NewInt(1)})
+	// arb has no Multicall3 deployed.
+	arb := newMockChain()
+	arb.balances(t, token, 6, map[string]int64{walletA: 1000})
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth, "arb": arb})
+	useMulticall(shared.Clients, map[string]string{"eth": "", "arb": ""})
+
+	rule := `{"and": [
+		{"network": "eth", "standard": "erc20", "amount": "1000", "contract": "` + token + `"},
+		{"network": "eth", "standard": "erc721", "amount": "ids:1-3", "contract": "` + nft + `"},
+		{"network": "eth", "standard": "native", "amount": "wei:1"}
+	]}`
+	status, response := post(t, router, "/api/rule", map[string]any{"rule": json.RawMessage(rule), "wallets": []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["success"], "Rule should pass")
+	assert.Equal(t, 1, eth.multicalls, "balanceOf, decimals and ownerOf should be packed into one aggregate3")
+	assert.Equal(t, 1, eth.calls, "Only aggregate3 should be sent as eth_call")
+	assert.Equal(t, 1, eth.requests, "eth_getBalance should be sent in the same batch")
+
+	// Reverted calls still fail checks that do not allow them.
+	status, _ = post(t, router, "/api/eth/erc20/1/"+unlisted, WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	// Without Multicall3 the calls are sent as a plain batch, at once and
+	// for every later request.
+	for i := 0; i < 2; i++ {
+		status, response = post(t, router, "/api/arb/erc20/1000/"+token, WalletRequest{Wallets: []string{walletA, walletB}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, true, response["success"], "Should fall back to plain batching")
+	}
+	assert.Equal(t, 1+3+3, arb.calls, "Should try aggregate3 once, then send plain calls")
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/gin-gonic/gin"
+)
+
+const errAdminDisabled = "Admin API is disabled, set adminToken or VULCAN_ADMIN_TOKEN"
+
+// handleNamedRule evaluates a rule stored under the name path parameter.
+func handleNamedRule(c *gin.Context, store *rules.Store, clients map[string]shared.RPCClient) {
+	rule, err := store.Get(c.Param("name"))
+	if errors.Is(err, rules.ErrRuleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, check := range rule.Checks() {
+		if err := validateCheck(check, clients); err != nil {
+			c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var req WalletRequest
+
+	if bindWallets(c, &req, &req) {
+		validateOwnership(c, clients, rule, req)
+	}
+}
+
+// requireAdmin rejects requests without the admin bearer token.
+func requireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errAdminDisabled})
+			return
+		}
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func registerAdminRoutes(router *gin.Engine, token string, store *rules.Store, clients map[string]shared.RPCClient) {
+	admin := router.Group("/admin", requireAdmin(token))
+
+	admin.GET("/rules", func(c *gin.Context) {
+		names, err := store.Names()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"rules": names})
+	})
+
+	admin.GET("/rules/:name", func(c *gin.Context) {
+		rule, err := store.Get(c.Param("name"))
+		if errors.Is(err, rules.ErrRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rule)
+	})
+
+	admin.PUT("/rules/:name", func(c *gin.Context) {
+		var rule rules.Rule
+		if err := c.ShouldBindJSON(&rule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := rule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, check := range rule.Checks() {
+			if err := validateCheck(check, clients); err != nil {
+				c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		err := store.Put(c.Param("name"), &rule)
+		if errors.Is(err, rules.ErrInvalidName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, &rule)
+	})
+
+	admin.DELETE("/rules/:name", func(c *gin.Context) {
+		err := store.Delete(c.Param("name"))
+		switch {
+		case errors.Is(err, rules.ErrRuleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, rules.ErrRuleReadOnly):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	})
+}
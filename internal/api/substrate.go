@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/metrics"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/FN00EU/vulcan-one/internal/substrate"
+	"github.com/FN00EU/vulcan-one/internal/units"
+	"github.com/gin-gonic/gin"
+)
+
+const errSubstrateSnapshot = "block and timestamp are not supported on Substrate networks"
+
+// substrateClients returns a client for every network of substrateNetworks,
+// sending storage queries in batches of batchSize. Networks without a URL are
+// skipped, and networks that are also EVM networks are an error.
+func substrateClients(networks map[string]string, evmNetworks map[string][]string, batchSize int) (map[string]*substrate.Client, error) {
+	clients := make(map[string]*substrate.Client)
+	for network, url := range networks {
+		if _, ok := evmNetworks[network]; ok {
+			return nil, fmt.Errorf("%s is both an EVM and a Substrate network", network)
+		}
+		if url == "" {
+			log.Printf("Substrate network %s has no URL, skipping it", network)
+			continue
+		}
+		client := substrate.NewClient(url, batchSize)
+		ctx, cancel := context.WithTimeout(context.Background(), substrate.DefaultTimeout)
+		properties, err := client.Properties(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Substrate network %s cannot be reached: %v", network, err)
+		} else {
+			log.Printf("Connected to %s. Token: %s, decimals: %d", network, properties.TokenSymbol, properties.TokenDecimals)
+		}
+		clients[network] = client
+	}
+	return clients, nil
+}
+
+// substrateStandardEnabled reports whether validStandards enables a standard
+// of Substrate networks, which has no aliases.
+func substrateStandardEnabled(name string) bool {
+	return len(shared.Config.ValidStandards) == 0 || slices.Contains(shared.Config.ValidStandards, name)
+}
+
+// handleSubstrateCheck evaluates a single check on a Substrate network for
+// the wallets of the request, which are SS58 addresses or hex account IDs.
+// validStandards and the contract allowList apply as on EVM networks, with
+// asset and collection IDs as contracts.
+func handleSubstrateCheck(c *gin.Context, client *substrate.Client, rule *rules.Rule) {
+	start := time.Now()
+	if !substrateStandardEnabled(rule.Standard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidStandard})
+		return
+	}
+	check, err := substrate.NewCheck(rule.Standard, rule.Contract, rule.Amount, shared.Limits())
+	switch {
+	case errors.Is(err, substrate.ErrUnknownStandard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidStandard})
+		return
+	case errors.Is(err, substrate.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		err = fmt.Errorf("%s: %w", errInvalidAmount, err)
+		c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if rule.Standard != substrate.StandardNative && !shared.AllowList.AllowsContract(rule.Network, rule.Contract) {
+		err := fmt.Errorf("%w: %s on %s", allowlist.ErrContractNotAllowed, rule.Contract, rule.Network)
+		c.JSON(checkStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var req WalletRequest
+	if !bindWallets(c, &req, &req) {
+		return
+	}
+	opts, err := requestOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.block != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errSubstrateSnapshot})
+		return
+	}
+
+	var accounts []substrate.AccountID
+	seen := make(map[substrate.AccountID]bool)
+	for _, wallet := range append([]string{req.Wallet}, req.Wallets...) {
+		if wallet == "" {
+			continue
+		}
+		account, err := substrate.ParseAccount(wallet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+
+	ctx := shared.Limits().WithCallBudget(c.Request.Context())
+	results, err := check.Results(ctx, client, accounts)
+	if err != nil {
+		observeCheck(rule, metrics.OutcomeError, time.Since(start))
+		switch {
+		case errors.Is(err, limits.ErrTooManyCalls):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, substrate.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, units.ErrTooManyDecimals):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, substrate.ErrRPC):
+			log.Println("Substrate error:", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			log.Println("Other Error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	report := substrateReport(ctx, client, rule, results, opts.aggregate)
+	outcome := metrics.OutcomeFail
+	if report.Success {
+		outcome = metrics.OutcomeSuccess
+	}
+	observeCheck(rule, outcome, time.Since(start))
+
+	response := gin.H{"success": report.Success}
+	if opts.verbose {
+		response["checks"] = []CheckReport{report}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// substrateReport compares the results like the report of an EVM check, with
+// the accounts as SS58 addresses of the network.
+func substrateReport(ctx context.Context, client *substrate.Client, rule *rules.Rule, results []substrate.Result, aggregate string) CheckReport {
+	report := CheckReport{
+		Network:  rule.Network,
+		Standard: rule.Standard,
+		Amount:   rule.Amount,
+		Contract: rule.Contract,
+	}
+
+	if aggregate == aggregateSum {
+		summed := make([]standard.Result, len(results))
+		for i, result := range results {
+			summed[i] = standard.Result{TokenID: result.Item, Balance: result.Balance, Threshold: result.Threshold}
+		}
+		results = nil
+		for _, sum := range standard.Sum(summed) {
+			results = append(results, substrate.Result{Item: sum.TokenID, Balance: sum.Balance, Threshold: sum.Threshold, Met: sum.Met})
+		}
+	}
+
+	// Addresses fall back to the generic format if the properties cannot
+	// be fetched.
+	format := uint16(42)
+	if properties, err := client.Properties(ctx); err == nil {
+		format = properties.SS58Format
+	}
+
+	report.Entries = make([]EntryReport, 0, len(results))
+	for i, result := range results {
+		entry := EntryReport{
+			Address:   result.Account.SS58(format),
+			Source:    sourceWallet,
+			Balance:   result.Balance.String(),
+			Threshold: result.Threshold.String(),
+			Met:       result.Met,
+		}
+		if aggregate == aggregateSum {
+			entry.Address, entry.Source = "", aggregateSum
+		}
+		if result.Item != nil {
+			entry.TokenID = result.Item.String()
+		}
+		if entry.Met && report.MetBy == nil {
+			metBy := i
+			report.MetBy = &metBy
+			report.Success = true
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	return report
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/substrate"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSubstrate is a fake Substrate node serving storage values by key.
+type mockSubstrate struct {
+	mu       sync.Mutex
+	storage  map[string]string
+	requests int
+}
+
+func newMockSubstrate() *mockSubstrate {
+	return &mockSubstrate{storage: make(map[string]string)}
+}
+
+// set stores a value under a storage key.
+func (m *mockSubstrate) set(key []byte, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage["0x"+hex.EncodeToString(key)] = "0x" + hex.EncodeToString(value)
+}
+
+func (m *mockSubstrate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []rpcMessage
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	responses := make([]map[string]any, len(batch))
+	for i, msg := range batch {
+		response := map[string]any{"jsonrpc": "2.0", "id": msg.ID}
+		var params []any
+		for _, raw := range msg.Params {
+			var param any
+			_ = json.Unmarshal(raw, &param)
+			params = append(params, param)
+		}
+		switch msg.Method {
+		case "system_properties":
+			response["result"] = map[string]any{"ss58Format": 2, "tokenDecimals": []int{12}, "tokenSymbol": []string{"KSM"}}
+		case "state_getStorage":
+			if value, ok := m.storage[params[0].(string)]; ok {
+				response["result"] = value
+			} else {
+				response["result"] = nil
+			}
+		case "state_getKeysPaged":
+			prefix, count := params[0].(string), int(params[1].(float64))
+			start := ""
+			if len(params) > 2 {
+				start = params[2].(string)
+			}
+			keys := []string{}
+			for key := range m.storage {
+				if strings.HasPrefix(key, prefix) && key > start {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			response["result"] = keys[:min(count, len(keys))]
+		default:
+			response["error"] = map[string]any{"code": -32601, "message": "Method not found"}
+		}
+		responses[i] = response
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(responses)
+}
+
+// setupMockSubstrate serves node as a Substrate network.
+func setupMockSubstrate(t *testing.T, network string, node *mockSubstrate) {
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	if shared.SubstrateClients == nil {
+		shared.SubstrateClients = make(map[string]*substrate.Client)
+	}
+	shared.SubstrateClients[network] = substrate.NewClient(srv.URL, 0)
+}
+
+// u128 SCALE encodes a balance.
+func u128(n int64) []byte {
+	value := make([]byte, 16)
+	big.NewInt(n).FillBytes(value)
+	for i, j := 0, len(value)-1; i < j; i, j = i+1, j-1 {
+		value[i], value[j] = value[j], value[i]
+	}
+	return value
+}
+
+func TestSubstrate(t *testing.T) {
+	const (
+		alice = "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"
+		bob   = "5FHneW46xGXgs5mUiveU4sbTyGBzmstUspZC92UhjJM694ty"
+		asset = 1984
+		nfts  = 7
+	)
+	aliceID, _ := substrate.ParseAccount(alice)
+	bobID, _ := substrate.ParseAccount(bob)
+
+	node := newMockSubstrate()
+	// AccountInfo: nonce, consumers, providers, sufficients, then free,
+	// reserved, frozen and flags.
+	node.set(substrate.StorageKey("System", "Account", aliceID[:]), append(append(make([]byte, 16), u128(1_500_000_000_000)...), make([]byte, 48)...))
+	node.set(substrate.StorageKey("System", "Account", bobID[:]), append(append(make([]byte, 16), u128(1_000_000_000_000)...), make([]byte, 48)...))
+
+	assetID := substrate.U32(asset)
+	node.set(substrate.StorageKey("Assets", "Asset", assetID), []byte{1})
+	// AssetMetadata: deposit, name, symbol, decimals and is_frozen.
+	metadata := append(u128(0), append([]byte{3 << 2}, "USD"...)...)
+	metadata = append(metadata, append([]byte{4 << 2}, "USDT"...)...)
+	node.set(substrate.StorageKey("Assets", "Metadata", assetID), append(metadata, 6, 0))
+	node.set(substrate.StorageKey("Assets", "Account", assetID, aliceID[:]), append(u128(2_500_000), 0, 0, 0))
+
+	collection := substrate.U32(nfts)
+	node.set(substrate.StorageKey("Nfts", "Collection", collection), []byte{1})
+	for _, item := range []uint32{1, 2} {
+		node.set(substrate.StorageKey("Nfts", "Account", aliceID[:], collection, substrate.U32(item)), nil)
+		node.set(substrate.StorageKey("Nfts", "Item", collection, substrate.U32(item)), append(aliceID[:], 0))
+	}
+
+	eth := newMockChain()
+	eth.nativeBalances(nil)
+	router := setupMockNetworks(t, map[string]*mockChain{"eth": eth})
+	setupMockSubstrate(t, "kusama", node)
+	shared.Config.ValidStandards = append(shared.Config.ValidStandards, substrate.StandardAssets, substrate.StandardNfts, substrate.StandardUniques)
+
+	testPaths(t, router, []pathTest{
+		{"/api/kusama/native/1.5", []string{alice}, http.StatusOK, true},
+		{"/api/kusama/native/1.6", []string{alice}, http.StatusOK, false},
+		{"/api/kusama/native/lt:1.5", []string{bob}, http.StatusOK, true},
+		{"/api/kusama/native/wei:1", []string{"5DAAnrj7VHTznn2AWBemMuyBwZWs6FNFjdyVXUeYum3PTXFy"}, http.StatusOK, false},
+		{"/api/kusama/native/1.5?aggregate=sum", []string{alice, bob}, http.StatusOK, true},
+		{"/api/kusama/native/2.6?aggregate=sum", []string{alice, bob}, http.StatusOK, false},
+		{"/api/kusama/native/0.0000000000001", []string{alice}, http.StatusBadRequest, false},
+		{"/api/kusama/assets/2.5/1984", []string{bob, alice}, http.StatusOK, true},
+		{"/api/kusama/assets/2.6/1984", []string{alice}, http.StatusOK, false},
+		{"/api/kusama/assets/1/1985", []string{alice}, http.StatusNotFound, false},
+		{"/api/kusama/assets/1/usdt", []string{alice}, http.StatusBadRequest, false},
+		{"/api/kusama/nfts/2/7", []string{alice}, http.StatusOK, true},
+		{"/api/kusama/nfts/3/7", []string{alice}, http.StatusOK, false},
+		{"/api/kusama/nfts/1/7", []string{bob}, http.StatusOK, false},
+		{"/api/kusama/nfts/ids:2,3/7", []string{alice}, http.StatusOK, true},
+		{"/api/kusama/nfts/ids:2/7", []string{bob}, http.StatusOK, false},
+		{"/api/kusama/uniques/1/7", []string{alice}, http.StatusNotFound, false},
+		{"/api/kusama/erc20/1/7", []string{alice}, http.StatusBadRequest, false},
+		{"/api/kusama/native/1", []string{walletA}, http.StatusBadRequest, false},
+		{"/api/kusama/native/1?block=5", []string{alice}, http.StatusBadRequest, false},
+	})
+
+	// Hex account IDs are accepted and reported as addresses of the network.
+	status, response := post(t, router, "/api/kusama/native/1?verbose=1", WalletRequest{Wallet: "0x" + hex.EncodeToString(aliceID[:])})
+	assert.Equal(t, http.StatusOK, status)
+	entries := response["checks"].([]any)[0].(map[string]any)["entries"].([]any)
+	assert.Equal(t, map[string]any{
+		"address":   aliceID.SS58(2),
+		"source":    sourceWallet,
+		"balance":   "1500000000000",
+		"threshold": "1000000000000",
+		"met":       true,
+	}, entries[0])
+
+	// Counting pages through the keys of large holdings.
+	for item := uint32(100); item < 1101; item++ {
+		node.set(substrate.StorageKey("Nfts", "Account", bobID[:], collection, substrate.U32(item)), nil)
+	}
+	status, response = post(t, router, "/api/kusama/nfts/eq:1001/7", WalletRequest{Wallet: bob})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["success"], "Every page of keys should be counted")
+
+	// EVM networks are unaffected.
+	status, _ = post(t, router, "/api/eth/native/0", WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusOK, status)
+
+	// validStandards and the allowList apply, with asset and collection IDs
+	// as contracts.
+	var err error
+	shared.AllowList, err = allowlist.New(allowlist.Config{Contracts: map[string][]string{"kusama": {"1984"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared.Config.ValidStandards = []string{"native", substrate.StandardAssets, substrate.StandardUniques}
+	testPaths(t, router, []pathTest{
+		{"/api/kusama/native/1.5", []string{alice}, http.StatusOK, true},
+		{"/api/kusama/assets/2.5/1984", []string{alice}, http.StatusOK, true},
+		{"/api/kusama/uniques/1/7", []string{alice}, http.StatusForbidden, false},
+		{"/api/kusama/nfts/2/7", []string{alice}, http.StatusBadRequest, false},
+	})
+	shared.AllowList = nil
+
+	// Storage queries are batched by the configured batch size.
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	shared.SubstrateClients["kusama"] = substrate.NewClient(srv.URL, 1)
+	requests := node.requests
+	status, _ = post(t, router, "/api/kusama/assets/2.5/1984", WalletRequest{Wallets: []string{alice, bob}})
+	assert.Equal(t, http.StatusOK, status)
+	// Asset, metadata and two accounts, and the properties of the new client.
+	assert.Equal(t, 5, node.requests-requests, "Every storage key should be sent alone")
+
+	down := httptest.NewServer(node)
+	down.Close()
+	shared.SubstrateClients["down"] = substrate.NewClient(down.URL, 0)
+	status, _ = post(t, router, "/api/down/native/1", WalletRequest{Wallet: alice})
+	assert.Equal(t, http.StatusBadGateway, status, "Unreachable nodes should fail with 502")
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/trn"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// trnNetworks are the networks with The Root Network precompiles.
+var trnNetworks = []string{"trn", "porcini"}
+
+// registerTRNRoutes registers the routes taking native asset and collection
+// IDs in place of precompile addresses.
+func registerTRNRoutes(group *gin.RouterGroup, clients map[string]shared.RPCClient) {
+	for _, network := range trnNetworks {
+		network := network
+		group.POST("/api/"+network+"/asset/:assetId/:amount", func(c *gin.Context) {
+			handleTRNAsset(c, clients, network)
+		})
+		group.POST("/api/"+network+"/collection/:collectionId/:amount", func(c *gin.Context) {
+			handleTRNCollection(c, clients, network)
+		})
+		group.GET("/api/"+network+"/futurepass/:address", func(c *gin.Context) {
+			handleFuturePass(c, clients, network)
+		})
+	}
+}
+
+// handleTRNAsset checks the balance of a native asset through its ERC-20
+// precompile.
+func handleTRNAsset(c *gin.Context, clients map[string]shared.RPCClient, network string) {
+	contract, err := trn.AssetIdToERC20Address(c.Param("assetId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	handleCheck(c, clients, rules.NewCheck(network, "erc20", c.Param("amount"), contract))
+}
+
+// handleTRNCollection checks the holdings of a collection through its
+// ERC-721 or ERC-1155 precompile, whichever pallet the collection is in. The
+// amount is given in the format of that standard.
+func handleTRNCollection(c *gin.Context, clients map[string]shared.RPCClient, network string) {
+	shared.ClientMutex.Lock()
+	client, exists := clients[network]
+	shared.ClientMutex.Unlock()
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidNetwork})
+		return
+	}
+
+	standard, contract, err := trn.CollectionStandard(c.Request.Context(), network, client, c.Param("collectionId"))
+	switch {
+	case errors.Is(err, trn.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, trn.ErrCollectionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	handleCheck(c, clients, rules.NewCheck(network, standard, c.Param("amount"), contract))
+}
+
+// handleFuturePass looks up the FuturePass of an address, or the owner if the
+// address is a FuturePass.
+func handleFuturePass(c *gin.Context, clients map[string]shared.RPCClient, network string) {
+	shared.ClientMutex.Lock()
+	client, exists := clients[network]
+	shared.ClientMutex.Unlock()
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidNetwork})
+		return
+	}
+	if !common.IsHexAddress(c.Param("address")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidAddress})
+		return
+	}
+	address := common.HexToAddress(c.Param("address"))
+	resolver := futurePassResolver(network)
+
+	var owner, futurePass common.Address
+	var err error
+	if trn.IsFuturePass(address) {
+		futurePass = address
+		owner, err = resolver.OwnerOf(c.Request.Context(), client, futurePass)
+	} else {
+		owner = address
+		var futurePasses []common.Address
+		futurePasses, err = resolver.FuturePassesOf(c.Request.Context(), client, []common.Address{owner}, nil)
+		if err == nil {
+			futurePass = futurePasses[0]
+		}
+	}
+	if err != nil {
+		log.Println("Linked account error:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Errorf("%w: %w", linked.ErrResolutionFailed, err).Error()})
+		return
+	}
+	if owner == (common.Address{}) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Errorf("%w: %s", trn.ErrNotFuturePass, address.Hex()).Error()})
+		return
+	}
+	if futurePass == (common.Address{}) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Errorf("%w: %s", trn.ErrNoFuturePass, address.Hex()).Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"owner": owner.Hex(), "futurepass": futurePass.Hex()})
+}
+
+// futurePassResolver returns the FuturePass resolver of a network, so lookups
+// share its cache, or a new one if the network does not resolve FuturePasses.
+func futurePassResolver(network string) *trn.FuturePassResolver {
+	for _, resolver := range shared.LinkedAccounts[network] {
+		if resolver, ok := resolver.(*trn.FuturePassResolver); ok {
+			return resolver
+		}
+	}
+	return trn.NewFuturePassResolver(0)
+}
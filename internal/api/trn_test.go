@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// futurePasses registers futurepassOf on the FuturePass precompile with the
+// FuturePass per owner; other owners have none.
+func (m *mockChain) futurePasses(t *testing.T, futurePasses map[string]string) {
+	m.handle("0x000000000000000000000000000000000000FFFF", "futurepassOf(address)", func(input []byte, block string) ([]byte, error) {
+		owner := unpack(t, []string{"address"}, input)[0].(common.Address)
+		for address, futurePass := range futurePasses {
+			if common.HexToAddress(address) == owner {
+				return pack(t, []string{"address"}, common.HexToAddress(futurePass)), nil
+			}
+		}
+		return pack(t, []string{"address"}, common.Address{}), nil
+	})
+}
+
+// supports registers supportsInterface on contract, answering true for the
+// given interface.
+func (m *mockChain) supports(t *testing.T, contract string, interfaceID [4]byte) {
+	m.handle(contract, "supportsInterface(bytes4)", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"bool"}, [4]byte(input[:4]) == interfaceID), nil
+	})
+}
+
+func TestTRNRoutes(t *testing.T) {
+	const (
+		asset      = "0xCCCCCCCC00000002000000000000000000000000"
+		collection = "0xAaaaAaaA00001064000000000000000000000000"
+		sftPallet  = "0xBBbbBbBB00001464000000000000000000000000"
+		limited    = "0xAaaaAaaA00001c64000000000000000000000000"
+	)
+	trnChain := newMockChain()
+	trnChain.futurePasses(t, nil)
+	trnChain.balances(t, asset, 6, map[string]int64{walletA: 1000})
+	trnChain.supports(t, collection, [4]byte{0x80, 0xac, 0x58, 0xcd})
+	trnChain.balances(t, collection, 0, map[string]int64{walletA: 2})
+	trnChain.supports(t, sftPallet, [4]byte{0xd9, 0xb6, 0x7a, 0x26})
+	trnChain.balances1155(t, sftPallet, map[string]map[int64]int64{walletB: {7: 3}})
+	trnChain.handle(limited, "supportsInterface(bytes4)", func(input []byte, block string) ([]byte, error) {
+		return nil, errRateLimited
+	})
+	porcini := newMockChain()
+	porcini.futurePasses(t, nil)
+	porcini.balances(t, asset, 6, map[string]int64{walletB: 5})
+	router := setupMockNetworks(t, map[string]*mockChain{"trn": trnChain, "porcini": porcini})
+
+	tests := []struct {
+		path    string
+		wallet  string
+		status  int
+		success bool
+	}{
+		{"/api/trn/asset/2/1000", walletA, http.StatusOK, true},
+		{"/api/trn/asset/2/1001", walletA, http.StatusOK, false},
+		{"/api/porcini/asset/2/5", walletB, http.StatusOK, true},
+		{"/api/trn/asset/x/1", walletA, http.StatusBadRequest, false},
+		{"/api/trn/asset/4294967296/1", walletA, http.StatusBadRequest, false},
+		{"/api/trn/collection/4196/2", walletA, http.StatusOK, true},
+		{"/api/trn/collection/4196/3", walletA, http.StatusOK, false},
+		{"/api/trn/collection/5220/7_3", walletB, http.StatusOK, true},
+		{"/api/trn/collection/5220/7_4", walletB, http.StatusOK, false},
+		{"/api/trn/collection/5220/x", walletB, http.StatusBadRequest, false},
+		{"/api/trn/collection/6244/1", walletA, http.StatusNotFound, false},
+		{"/api/porcini/collection/4196/1", walletA, http.StatusNotFound, false},
+		// A node failure says nothing about the collection.
+		{"/api/trn/collection/7268/1", walletA, http.StatusServiceUnavailable, false},
+		// Addresses still work next to the ID routes.
+		{"/api/trn/erc20/1000/" + asset, walletA, http.StatusOK, true},
+	}
+
+	for _, test := range tests {
+		status, response := post(t, router, test.path, WalletRequest{Wallet: test.wallet})
+		assert.Equal(t, test.status, status, test.path)
+		if status == http.StatusOK {
+			assert.Equal(t, test.success, response["success"], test.path)
+		}
+	}
+}
+
+func TestFuturePassLookup(t *testing.T) {
+	const (
+		futurePass = "0xFfFFFFff00000000000000000000000000000001"
+		unused     = "0xFfFFFFff00000000000000000000000000000002"
+		flaky      = "0xFfFFFFff00000000000000000000000000000003"
+	)
+	chain := newMockChain()
+	chain.futurePasses(t, map[string]string{walletA: futurePass})
+	chain.handle(futurePass, "owner()", func(input []byte, block string) ([]byte, error) {
+		return pack(t, []string{"address"}, common.HexToAddress(walletA)), nil
+	})
+	// flaky answers owner() with malformed data until it recovers.
+	recovered := false
+	chain.handle(flaky, "owner()", func(input []byte, block string) ([]byte, error) {
+		if !recovered {
+			return []byte{0x01}, nil
+		}
+		return pack(t, []string{"address"}, common.HexToAddress(walletB)), nil
+	})
+	chain.nativeBalances(nil)
+	// porcini has no FuturePass registry and fails every lookup.
+	router := setupMockNetworks(t, map[string]*mockChain{"trn": chain, "porcini": newMockChain()})
+
+	tests := []struct {
+		path     string
+		status   int
+		response map[string]any
+	}{
+		{"/api/trn/futurepass/" + walletA, http.StatusOK, map[string]any{"owner": common.HexToAddress(walletA).Hex(), "futurepass": common.HexToAddress(futurePass).Hex()}},
+		{"/api/trn/futurepass/" + futurePass, http.StatusOK, map[string]any{"owner": common.HexToAddress(walletA).Hex(), "futurepass": common.HexToAddress(futurePass).Hex()}},
+		{"/api/trn/futurepass/" + walletB, http.StatusNotFound, nil},
+		{"/api/trn/futurepass/" + unused, http.StatusNotFound, nil},
+		{"/api/trn/futurepass/0x1234", http.StatusBadRequest, nil},
+		{"/api/porcini/futurepass/" + walletA, http.StatusBadGateway, nil},
+	}
+	for _, test := range tests {
+		status, response := get(t, router, test.path)
+		assert.Equal(t, test.status, status, test.path)
+		if test.response != nil {
+			assert.Equal(t, test.response, response, test.path)
+		}
+	}
+
+	calls := chain.calls
+	get(t, router, "/api/trn/futurepass/"+walletA)
+	get(t, router, "/api/trn/futurepass/"+futurePass)
+	status, _ := post(t, router, "/api/trn/native/0", WalletRequest{Wallets: []string{walletA, walletB}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, calls, chain.calls, "Lookups should be cached")
+
+	// Failures other than a revert are not taken as "not created" and are
+	// not cached.
+	status, response := get(t, router, "/api/trn/futurepass/"+flaky)
+	assert.Equal(t, http.StatusBadGateway, status, "Malformed owner should fail the lookup")
+	assert.Contains(t, response["error"], "FuturePass lookup failed")
+	recovered = true
+	status, _ = get(t, router, "/api/trn/futurepass/"+flaky)
+	assert.Equal(t, http.StatusOK, status, "Failed lookups should not be cached")
+
+	// A failed lookup fails the request instead of checking fewer addresses.
+	status, response = post(t, router, "/api/porcini/native/0", WalletRequest{Wallet: walletA})
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Contains(t, response["error"], "linked account resolution failed")
+}
@@ -0,0 +1,156 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Op is a comparison of a balance with the amount of a check.
+type Op string
+
+const (
+	Gte     Op = "gte"
+	Gt      Op = "gt"
+	Lt      Op = "lt"
+	Lte     Op = "lte"
+	Eq      Op = "eq"
+	Between Op = "between"
+
+	// rawPrefix is kept together with the value that follows it, so
+	// amounts such as lt:wei:100 parse as expected.
+	rawPrefix = "wei"
+)
+
+var (
+	ErrInvalidInteger   = errors.New("amount must be a non-negative integer")
+	ErrInvalidCondition = errors.New("amount must be a value, op:value with op one of gte, gt, lt, lte, eq, or between:min:max")
+	ErrEmptyRange       = errors.New("between needs min <= max")
+)
+
+// Condition is a parsed but not yet converted comparison. Amounts are kept as
+// strings, so each standard can convert them into its own units.
+type Condition struct {
+	Op  Op
+	Min string
+	Max string
+}
+
+// Parse parses a comparison of the form value (at least value), op:value or
+// between:min:max, where min and max are inclusive.
+func Parse(s string) (Condition, error) {
+	parts := joinRaw(strings.Split(s, ":"))
+
+	switch {
+	case len(parts) == 1:
+		return Condition{Op: Gte, Min: parts[0]}, nil
+	case len(parts) == 2 && isUnary(Op(parts[0])):
+		return Condition{Op: Op(parts[0]), Min: parts[1]}, nil
+	case len(parts) == 3 && Op(parts[0]) == Between:
+		return Condition{Op: Between, Min: parts[1], Max: parts[2]}, nil
+	}
+	return Condition{}, fmt.Errorf("%w: %q", ErrInvalidCondition, s)
+}
+
+func isUnary(op Op) bool {
+	switch op {
+	case Gte, Gt, Lt, Lte, Eq:
+		return true
+	}
+	return false
+}
+
+// joinRaw rejoins "wei" with the value after it.
+func joinRaw(parts []string) []string {
+	joined := make([]string, 0, len(parts))
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == rawPrefix && i+1 < len(parts) {
+			joined = append(joined, parts[i]+":"+parts[i+1])
+			i++
+			continue
+		}
+		joined = append(joined, parts[i])
+	}
+	return joined
+}
+
+// Convert converts the amounts of the condition with parse.
+func (c Condition) Convert(parse func(string) (*big.Int, error)) (Threshold, error) {
+	min, err := parse(c.Min)
+	if err != nil {
+		return Threshold{}, err
+	}
+	t := Threshold{Op: c.Op, Min: min}
+	if c.Op != Between {
+		return t, nil
+	}
+
+	if t.Max, err = parse(c.Max); err != nil {
+		return Threshold{}, err
+	}
+	if t.Min.Cmp(t.Max) > 0 {
+		return Threshold{}, fmt.Errorf("%w: %s > %s", ErrEmptyRange, c.Min, c.Max)
+	}
+	return t, nil
+}
+
+// Integer converts an amount of a standard without decimals, such as a
+// number of NFTs. It can be passed to Condition.Convert.
+func Integer(amount string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidInteger, amount)
+	}
+	return value, nil
+}
+
+// Threshold is a condition with amounts in the smallest unit of a token.
+type Threshold struct {
+	Op  Op
+	Min *big.Int
+	Max *big.Int
+}
+
+// AtLeast returns the default threshold, balance >= amount.
+func AtLeast(amount *big.Int) Threshold {
+	return Threshold{Op: Gte, Min: amount}
+}
+
+// Met reports whether a balance satisfies the threshold. A nil balance never
+// does.
+func (t Threshold) Met(balance *big.Int) bool {
+	if balance == nil || t.Min == nil {
+		return false
+	}
+	cmp := balance.Cmp(t.Min)
+	switch t.Op {
+	case Gte:
+		return cmp >= 0
+	case Gt:
+		return cmp > 0
+	case Lt:
+		return cmp < 0
+	case Lte:
+		return cmp <= 0
+	case Eq:
+		return cmp == 0
+	case Between:
+		return cmp >= 0 && t.Max != nil && balance.Cmp(t.Max) <= 0
+	}
+	return false
+}
+
+// String formats the threshold in the syntax of Parse. The default operator
+// is left out, so it reads as the plain amount.
+func (t Threshold) String() string {
+	switch {
+	case t.Min == nil:
+		return ""
+	case t.Op == Gte:
+		return t.Min.String()
+	case t.Op == Between:
+		return fmt.Sprintf("%s:%s:%s", t.Op, t.Min, t.Max)
+	}
+	return fmt.Sprintf("%s:%s", t.Op, t.Min)
+}
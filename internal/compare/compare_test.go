@@ -0,0 +1,83 @@
+package compare
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Condition
+		err   bool
+	}{
+		{"5", Condition{Op: Gte, Min: "5"}, false},
+		{"lt:5", Condition{Op: Lt, Min: "5"}, false},
+		{"lte:0", Condition{Op: Lte, Min: "0"}, false},
+		{"eq:3", Condition{Op: Eq, Min: "3"}, false},
+		{"gt:0.5", Condition{Op: Gt, Min: "0.5"}, false},
+		{"gte:2", Condition{Op: Gte, Min: "2"}, false},
+		{"between:1:4", Condition{Op: Between, Min: "1", Max: "4"}, false},
+		{"wei:100", Condition{Op: Gte, Min: "wei:100"}, false},
+		{"lt:wei:100", Condition{Op: Lt, Min: "wei:100"}, false},
+		{"between:wei:1:2.5", Condition{Op: Between, Min: "wei:1", Max: "2.5"}, false},
+		{"between:1", Condition{}, true},
+		{"between:1:2:3", Condition{}, true},
+		{"most:5", Condition{}, true},
+		{"lt:1:2", Condition{}, true},
+	}
+
+	for _, test := range tests {
+		condition, err := Parse(test.input)
+		if test.err {
+			assert.ErrorIs(t, err, ErrInvalidCondition, "Should fail for %q", test.input)
+			continue
+		}
+		assert.NoError(t, err, "Should parse %q", test.input)
+		assert.Equal(t, test.want, condition, "Condition should match for %q", test.input)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		input   string
+		balance int64
+		met     bool
+	}{
+		{"5", 5, true},
+		{"5", 4, false},
+		{"gt:5", 5, false},
+		{"gt:5", 6, true},
+		{"lt:5", 4, true},
+		{"lt:5", 5, false},
+		{"lte:0", 0, true},
+		{"lte:0", 1, false},
+		{"eq:3", 3, true},
+		{"eq:3", 4, false},
+		{"between:1:4", 0, false},
+		{"between:1:4", 1, true},
+		{"between:1:4", 4, true},
+		{"between:1:4", 5, false},
+	}
+
+	for _, test := range tests {
+		condition, err := Parse(test.input)
+		assert.NoError(t, err)
+		threshold, err := condition.Convert(Integer)
+		assert.NoError(t, err)
+		assert.Equal(t, test.met, threshold.Met(big.NewInt(test.balance)), "%s with balance %d", test.input, test.balance)
+		assert.Equal(t, test.input, threshold.String(), "String should round-trip")
+	}
+
+	assert.False(t, AtLeast(big.NewInt(0)).Met(nil), "Nil balance should never meet")
+
+	condition, _ := Parse("between:4:1")
+	_, err := condition.Convert(Integer)
+	assert.ErrorIs(t, err, ErrEmptyRange)
+
+	condition, _ = Parse("lt:-1")
+	_, err = condition.Convert(Integer)
+	assert.ErrorIs(t, err, ErrInvalidInteger)
+}
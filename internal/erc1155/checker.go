@@ -0,0 +1,111 @@
+package erc1155
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var funcBalanceOfBatch = w3.MustNewFunc("balanceOfBatch(address[],uint256[])", "uint256[]")
+
+func init() {
+	standard.Register(Checker{})
+}
+
+// Checker checks that a wallet holds at least the amount of any of the token
+// IDs of an ERC-1155 contract. The IDs and amounts are given as a selector
+// such as 1-10_2&0x2a_lt:5, see Parse. A mode prefix requires all of the
+// IDs, K distinct IDs or a total quantity instead, see ParseAmount.
+type Checker struct{}
+
+func (Checker) Names() []string {
+	return []string{"erc1155", "sft"}
+}
+
+func (Checker) NewCheck(network, contract, amount string) (standard.Check, error) {
+	if contract == "" {
+		return nil, standard.ErrMissingContract
+	}
+	parsed, err := ParseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	c := &check{contract: w3.A(contract), tokenIDs: parsed.Selector.IDs, amounts: parsed.Selector.Amounts}
+	if parsed.Mode != ModeAny {
+		return &combinedCheck{check: c, amount: parsed}, nil
+	}
+	return c, nil
+}
+
+type check struct {
+	contract common.Address
+	tokenIDs []*big.Int
+	amounts  []compare.Threshold
+
+	// address, token ID and amount of each fetched balance
+	addresses    []common.Address
+	batchIDs     []*big.Int
+	batchAmounts []compare.Threshold
+	balances     []*big.Int
+
+	// balances of each balanceOfBatch call
+	chunks [][]*big.Int
+}
+
+// Calls returns balanceOfBatch calls of at most batchSize of the limits
+// configuration (address, token ID) pairs each.
+func (c *check) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	hexAddresses := make([]string, len(addresses))
+	for i, address := range addresses {
+		hexAddresses[i] = address.Hex()
+	}
+	c.addresses, c.batchIDs, c.batchAmounts = GenerateCombinations(hexAddresses, c.tokenIDs, c.amounts)
+
+	batchSize := shared.Limits().BatchSize
+	c.chunks = make([][]*big.Int, (len(c.addresses)+batchSize-1)/batchSize)
+	calls := make([]w3types.Caller, len(c.chunks))
+	for i := range c.chunks {
+		start, end := i*batchSize, min((i+1)*batchSize, len(c.addresses))
+		calls[i] = eth.CallFunc(c.contract, funcBalanceOfBatch, c.addresses[start:end], c.batchIDs[start:end]).AtBlock(blockNumber).Returns(&c.chunks[i])
+	}
+	return calls
+}
+
+func (c *check) Results() ([]standard.Result, error) {
+	c.balances = c.balances[:0]
+	for _, chunk := range c.chunks {
+		c.balances = append(c.balances, chunk...)
+	}
+	if len(c.balances) > len(c.batchAmounts) {
+		return nil, errors.New("balanceOfBatch returned more balances than requested")
+	}
+
+	results := make([]standard.Result, len(c.balances))
+	for i, balance := range c.balances {
+		results[i] = standard.Result{
+			Address:   c.addresses[i],
+			TokenID:   c.batchIDs[i],
+			Balance:   balance,
+			Threshold: c.batchAmounts[i],
+			Met:       c.batchAmounts[i].Met(balance),
+		}
+	}
+	return results, nil
+}
+
+// combinedCheck is a check in the all, distinct or total mode.
+type combinedCheck struct {
+	*check
+	amount Amount
+}
+
+func (c *combinedCheck) Combine(results []standard.Result) []standard.Result {
+	return c.amount.combine(results)
+}
@@ -1,11 +1,14 @@
 package erc1155_test
 
 import (
+	"errors"
 	"math/big"
 	"reflect"
 	"testing"
 
+	"github.com/FN00EU/vulcan-one/internal/compare"
 	"github.com/FN00EU/vulcan-one/internal/erc1155"
+	"github.com/FN00EU/vulcan-one/internal/limits"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
@@ -42,56 +45,71 @@ func TestGenerateCombinations(t *testing.T) {
 	assert.Equal(t, expectedTokenAmounts, multipliedTokenAmounts, "Should be equal")
 }
 
-func TestReturnValidERC1155Format(t *testing.T) {
-	// Test case 1: Valid exact match
-	str := "44_1&44_1"
-	result, err := erc1155.ReturnValidERC1155Format(str)
-	assert.NoError(t, err, "Should not return an error")
-	assert.Equal(t, "aformat", result, "Should return 'aformat'")
-
-	// Test case 2: Valid range match
-	str = "1-10"
-	result, err = erc1155.ReturnValidERC1155Format(str)
-	assert.NoError(t, err, "Should not return an error")
-	assert.Equal(t, "-format", result, "Should return '-format'")
-
-	// Test case 3: Invalid range match
-
-	// Test case 4: Invalid input
-	str = "invalid_format"
-	result, err = erc1155.ReturnValidERC1155Format(str)
-	assert.Error(t, err, "Should return an error")
-	assert.Empty(t, result, "Should return an empty string")
-
-	// Test case 5: Error in regex compilation
-	invalidRegexStr := "invalid[regex"
-	result, err = erc1155.ReturnValidERC1155Format(invalidRegexStr)
-	assert.Error(t, err, "Should return an error")
-	assert.Empty(t, result, "Should return an empty string")
-}
-
 func TestParseERC1155(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedIds     []*big.Int
-		expectedAmounts []*big.Int
+		expectedAmounts []compare.Threshold
 		expectError     bool
 	}{
 		// Test case for "aformat"
 		{
 			input:           "123_1&456_2&789_3",
 			expectedIds:     []*big.Int{big.NewInt(123), big.NewInt(456), big.NewInt(789)},
-			expectedAmounts: []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+			expectedAmounts: []compare.Threshold{compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(2)), compare.AtLeast(big.NewInt(3))},
 			expectError:     false,
 		},
 		// Test case for "-format"
 		{
 			input:           "100-105",
 			expectedIds:     []*big.Int{big.NewInt(100), big.NewInt(101), big.NewInt(102), big.NewInt(103), big.NewInt(104), big.NewInt(105)},
-			expectedAmounts: []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1)},
+			expectedAmounts: []compare.Threshold{compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1))},
+			expectError:     false,
+		},
+		// Test case for comparison operators
+		{
+			input:           "1_lt:5&2_between:1:3&3_eq:0",
+			expectedIds:     []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+			expectedAmounts: []compare.Threshold{{Op: compare.Lt, Min: big.NewInt(5)}, {Op: compare.Between, Min: big.NewInt(1), Max: big.NewInt(3)}, {Op: compare.Eq, Min: big.NewInt(0)}},
 			expectError:     false,
 		},
-		// Add more test cases as needed
+		{
+			input:       "1_between:3:1",
+			expectError: true,
+		},
+		{
+			input:       "1_most:5",
+			expectError: true,
+		},
+		// Test cases for the combined grammar
+		{
+			input:           "1-3_2",
+			expectedIds:     []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+			expectedAmounts: []compare.Threshold{compare.AtLeast(big.NewInt(2)), compare.AtLeast(big.NewInt(2)), compare.AtLeast(big.NewInt(2))},
+		},
+		{
+			input:           "1-2&5-6",
+			expectedIds:     []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(5), big.NewInt(6)},
+			expectedAmounts: []compare.Threshold{compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1))},
+		},
+		{
+			input:           "0x2a_lt:3&7&8-9_eq:0",
+			expectedIds:     []*big.Int{big.NewInt(42), big.NewInt(7), big.NewInt(8), big.NewInt(9)},
+			expectedAmounts: []compare.Threshold{{Op: compare.Lt, Min: big.NewInt(3)}, compare.AtLeast(big.NewInt(1)), {Op: compare.Eq, Min: big.NewInt(0)}, {Op: compare.Eq, Min: big.NewInt(0)}},
+		},
+		{
+			input:           "0xA-0xB",
+			expectedIds:     []*big.Int{big.NewInt(10), big.NewInt(11)},
+			expectedAmounts: []compare.Threshold{compare.AtLeast(big.NewInt(1)), compare.AtLeast(big.NewInt(1))},
+		},
+		{input: "5-3", expectError: true},
+		{input: "1-3&", expectError: true},
+		{input: "", expectError: true},
+		{input: "1_", expectError: true},
+		{input: "0x", expectError: true},
+		{input: "1-2-3", expectError: true},
+		{input: "0xg", expectError: true},
+		{input: "1-1001", expectError: true},
 	}
 
 	for _, test := range tests {
@@ -111,3 +129,68 @@ func TestParseERC1155(t *testing.T) {
 		}
 	}
 }
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		err   error
+	}{
+		{"1_2&x_3", 5, erc1155.ErrInvalidSelector},
+		{"1_2&3_most:5", 7, compare.ErrInvalidCondition},
+		{"1_2&&3", 5, erc1155.ErrInvalidSelector},
+		{"1-0x1g", 6, erc1155.ErrInvalidSelector},
+		{"10-5_1", 1, erc1155.ErrInvalidSelector},
+		{"1_1&1-2000", 5, limits.ErrTooManyTokenIDs},
+	}
+
+	for _, test := range tests {
+		_, err := erc1155.Parse(test.input)
+		var syntaxErr *erc1155.SyntaxError
+		if !assert.True(t, errors.As(err, &syntaxErr), "Selector %q should fail with a SyntaxError, got %v", test.input, err) {
+			continue
+		}
+		assert.Equal(t, test.pos, syntaxErr.Pos, "Position should match for %q: %v", test.input, err)
+		assert.True(t, errors.Is(err, test.err), "Selector %q should fail with %v, got %v", test.input, test.err, err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input       string
+		mode        erc1155.Mode
+		count       int64
+		ids         int
+		expectError bool
+	}{
+		{input: "1-5", mode: erc1155.ModeAny, ids: 5},
+		{input: "1_between:1:3", mode: erc1155.ModeAny, ids: 1},
+		{input: "all:1-5", mode: erc1155.ModeAll, ids: 5},
+		{input: "all:1_2&3_lt:5", mode: erc1155.ModeAll, ids: 2},
+		{input: "distinct:3:1-10", mode: erc1155.ModeDistinct, count: 3, ids: 10},
+		{input: "total:10:1-5", mode: erc1155.ModeTotal, count: 10, ids: 5},
+		{input: "distinct:11:1-10", expectError: true},
+		{input: "distinct:0:1-10", expectError: true},
+		{input: "distinct:1-10", expectError: true},
+		{input: "total:x:1-5", expectError: true},
+		{input: "total:10:1-5_2", expectError: true},
+		{input: "all:", expectError: true},
+		{input: "some:1-5", expectError: true},
+	}
+
+	for _, test := range tests {
+		amount, err := erc1155.ParseAmount(test.input)
+		if test.expectError {
+			assert.Error(t, err, "Amount %q should fail", test.input)
+			continue
+		}
+		if !assert.NoError(t, err, "Amount %q should parse", test.input) {
+			continue
+		}
+		assert.Equal(t, test.mode, amount.Mode, "Mode should match for %q", test.input)
+		assert.Len(t, amount.Selector.IDs, test.ids, "IDs should match for %q", test.input)
+		if test.count > 0 {
+			assert.Equal(t, big.NewInt(test.count), amount.Count, "Count should match for %q", test.input)
+		}
+	}
+}
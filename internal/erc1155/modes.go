@@ -0,0 +1,149 @@
+package erc1155
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Mode is how the token IDs of a selector are combined.
+type Mode string
+
+const (
+	// ModeAny is met by any single token ID, the default.
+	ModeAny Mode = ""
+	// ModeAll is met by holding every token ID, such as all chapters of a
+	// comic.
+	ModeAll Mode = "all"
+	// ModeDistinct is met by holding at least K distinct token IDs.
+	ModeDistinct Mode = "distinct"
+	// ModeTotal is met by a total quantity of at least N over all token IDs.
+	ModeTotal Mode = "total"
+)
+
+var ErrInvalidMode = errors.New("amount must be a selector, all:selector, distinct:K:selector or total:N:selector")
+
+// Amount is a parsed amount of the erc1155 standard.
+type Amount struct {
+	Mode     Mode
+	Count    *big.Int
+	Selector Selector
+}
+
+// ParseAmount parses a selector, optionally prefixed with a mode:
+//
+//	all:1-5           every token ID 1 to 5
+//	distinct:3:1-10   at least 3 distinct token IDs from 1 to 10
+//	total:10:1-5      at least 10 tokens of IDs 1 to 5 together
+//
+// The amounts of the selector apply to each token ID in the all and
+// distinct modes; total takes token IDs only.
+func ParseAmount(amount string) (Amount, error) {
+	mode, rest, ok := strings.Cut(amount, ":")
+	if !ok {
+		mode, rest = "", amount
+	}
+
+	a := Amount{Mode: Mode(mode)}
+	switch a.Mode {
+	case ModeAll:
+	case ModeDistinct, ModeTotal:
+		count, selector, ok := strings.Cut(rest, ":")
+		if !ok {
+			return Amount{}, fmt.Errorf("%w: %q", ErrInvalidMode, amount)
+		}
+		n, err := compare.Integer(count)
+		if err != nil || n.Sign() == 0 {
+			return Amount{}, fmt.Errorf("%w: %s needs a positive count, got %q", ErrInvalidMode, mode, count)
+		}
+		a.Count, rest = n, selector
+	default:
+		a.Mode, rest = ModeAny, amount
+	}
+
+	var err error
+	if a.Selector, err = Parse(rest); err != nil {
+		return Amount{}, err
+	}
+
+	switch a.Mode {
+	case ModeDistinct:
+		if ids := len(distinctIDs(a.Selector.IDs)); a.Count.Cmp(big.NewInt(int64(ids))) > 0 {
+			return Amount{}, fmt.Errorf("%w: distinct:%s but the selector has %d token IDs", ErrInvalidMode, a.Count, ids)
+		}
+	case ModeTotal:
+		if a.Selector.HasAmounts {
+			return Amount{}, fmt.Errorf("%w: total takes token IDs without amounts", ErrInvalidMode)
+		}
+	}
+	return a, nil
+}
+
+func distinctIDs(ids []*big.Int) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if key := id.String(); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// combine turns the results of every address into one result of the mode.
+// A token ID counts as held if every amount given for it is met; in the
+// total mode each token ID is counted once.
+func (a Amount) combine(results []standard.Result) []standard.Result {
+	type holdings struct {
+		met     map[string]bool
+		balance map[string]*big.Int
+	}
+	var order []common.Address
+	byAddress := make(map[common.Address]*holdings)
+	for _, result := range results {
+		h, ok := byAddress[result.Address]
+		if !ok {
+			h = &holdings{met: make(map[string]bool), balance: make(map[string]*big.Int)}
+			byAddress[result.Address] = h
+			order = append(order, result.Address)
+		}
+		key := result.TokenID.String()
+		if met, seen := h.met[key]; seen {
+			h.met[key] = met && result.Met
+			continue
+		}
+		h.met[key] = result.Met
+		h.balance[key] = result.Balance
+	}
+
+	threshold := compare.AtLeast(a.Count)
+	if a.Mode == ModeAll {
+		threshold = compare.AtLeast(big.NewInt(int64(len(distinctIDs(a.Selector.IDs)))))
+	}
+	combined := make([]standard.Result, len(order))
+	for i, address := range order {
+		h := byAddress[address]
+		value := new(big.Int)
+		for key, met := range h.met {
+			switch {
+			case a.Mode == ModeTotal && h.balance[key] != nil:
+				value.Add(value, h.balance[key])
+			case a.Mode != ModeTotal && met:
+				value.Add(value, big.NewInt(1))
+			}
+		}
+		combined[i] = standard.Result{
+			Address:   address,
+			Balance:   value,
+			Threshold: threshold,
+			Met:       threshold.Met(value),
+		}
+	}
+	return combined
+}
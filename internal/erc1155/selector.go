@@ -0,0 +1,160 @@
+package erc1155
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+)
+
+var ErrInvalidSelector = errors.New("invalid token selector")
+
+// Selector is a parsed list of token IDs with the amount required of each.
+// HasAmounts is set if any term gives an amount.
+type Selector struct {
+	IDs        []*big.Int
+	Amounts    []compare.Threshold
+	HasAmounts bool
+}
+
+// SyntaxError is an invalid selector. Pos is the 1-based position of the
+// offending part in the input.
+type SyntaxError struct {
+	Input string
+	Pos   int
+	Msg   string
+	Err   error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s %q at position %d: %s", ErrInvalidSelector, e.Input, e.Pos, e.Msg)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses a selector of the grammar
+//
+//	selector = term { "&" term }
+//	term     = id [ "-" id ] [ "_" amount ]
+//	id       = decimal | "0x" hex
+//
+// where id-id is an inclusive range whose IDs all get the amount of the
+// term, and amount is a count with an optional comparison operator, see
+// compare.Parse. Without an amount, at least 1 of the ID is required. For
+// example 1-10_2&0x2a_between:1:3&99. The expanded token IDs are bounded by
+// maxTokenIds of the limits configuration.
+func Parse(input string) (Selector, error) {
+	p := parser{input: input, limits: shared.Limits()}
+	var selector Selector
+	for _, term := range split(input, '&') {
+		if err := p.term(&selector, term.offset, term.text); err != nil {
+			return Selector{}, err
+		}
+	}
+	return selector, nil
+}
+
+type parser struct {
+	input  string
+	limits limits.Config
+}
+
+func (p parser) fail(offset int, err error, format string, args ...any) error {
+	if err == nil {
+		err = ErrInvalidSelector
+	}
+	return &SyntaxError{Input: p.input, Pos: offset + 1, Msg: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (p parser) term(selector *Selector, offset int, term string) error {
+	if term == "" {
+		return p.fail(offset, nil, "empty term")
+	}
+	ids, amount, hasAmount := strings.Cut(term, "_")
+
+	threshold := compare.AtLeast(big.NewInt(1))
+	if hasAmount {
+		selector.HasAmounts = true
+		amountOffset := offset + len(ids) + 1
+		if amount == "" {
+			return p.fail(amountOffset, nil, "missing amount after _")
+		}
+		condition, err := compare.Parse(amount)
+		if err == nil {
+			threshold, err = condition.Convert(compare.Integer)
+		}
+		if err != nil {
+			return p.fail(amountOffset, err, "%v", err)
+		}
+	}
+
+	first, last, isRange := strings.Cut(ids, "-")
+	start, err := p.id(offset, first)
+	if err != nil {
+		return err
+	}
+	end := start
+	if isRange {
+		if end, err = p.id(offset+len(first)+1, last); err != nil {
+			return err
+		}
+		if start.Cmp(end) > 0 {
+			return p.fail(offset, nil, "range %s is empty, the start is after the end", ids)
+		}
+	}
+
+	// Checked before the range is expanded, so huge ranges allocate nothing.
+	count := new(big.Int).Sub(end, start)
+	if count.Cmp(big.NewInt(int64(p.limits.MaxTokenIDs-len(selector.IDs)))) >= 0 {
+		err := p.limits.TokenIDs(p.limits.MaxTokenIDs + 1)
+		return p.fail(offset, err, "%v", err)
+	}
+	for id := start; id.Cmp(end) <= 0; id = new(big.Int).Add(id, big.NewInt(1)) {
+		selector.IDs = append(selector.IDs, id)
+		selector.Amounts = append(selector.Amounts, threshold)
+	}
+	return nil
+}
+
+// id parses a decimal or 0x prefixed hexadecimal token ID.
+func (p parser) id(offset int, s string) (*big.Int, error) {
+	digits, base := s, 10
+	if hex, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		digits, base = hex, 16
+	}
+	if digits == "" {
+		return nil, p.fail(offset, nil, "missing token ID")
+	}
+	for i, r := range strings.ToLower(digits) {
+		if r >= '0' && r <= '9' || base == 16 && r >= 'a' && r <= 'f' {
+			continue
+		}
+		return nil, p.fail(offset+len(s)-len(digits)+i, nil, "unexpected %q in token ID", r)
+	}
+	id, _ := new(big.Int).SetString(digits, base)
+	return id, nil
+}
+
+type part struct {
+	offset int
+	text   string
+}
+
+// split splits s at sep and keeps the offset of every part in s.
+func split(s string, sep byte) []part {
+	var parts []part
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == sep {
+			parts = append(parts, part{offset: start, text: s[start:i]})
+			start = i + 1
+		}
+	}
+	return parts
+}
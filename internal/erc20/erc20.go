@@ -0,0 +1,102 @@
+package erc20
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/FN00EU/vulcan-one/internal/units"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var (
+	funcBalanceOf = w3.MustNewFunc("balanceOf(address)", "uint256")
+	funcDecimals  = w3.MustNewFunc("decimals()", "uint8")
+)
+
+func init() {
+	standard.Register(Checker{})
+}
+
+// Checker checks that a wallet holds at least amount tokens of an ERC-20
+// contract. The amount is a decimal number of tokens, such as 0.25 or 1.5e3,
+// or a number of the smallest unit prefixed with wei:, and may be preceded by
+// a comparison operator such as lt: or between:min:max.
+type Checker struct{}
+
+func (Checker) Names() []string {
+	return []string{"erc20", "token"}
+}
+
+func (Checker) NewCheck(network, contract, amount string) (standard.Check, error) {
+	if contract == "" {
+		return nil, standard.ErrMissingContract
+	}
+	condition, err := compare.Parse(amount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := condition.Convert(parseAmount); err != nil {
+		return nil, err
+	}
+	return &check{contract: w3.A(contract), condition: condition}, nil
+}
+
+// parseAmount validates an amount before the decimals are known.
+func parseAmount(amount string) (*big.Int, error) {
+	_, err := units.Parse(amount)
+	return new(big.Int), err
+}
+
+type check struct {
+	contract  common.Address
+	condition compare.Condition
+	decimals  *uint8
+	addresses []common.Address
+	balances  []*big.Int
+}
+
+func (c *check) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	c.addresses = addresses
+	c.balances = make([]*big.Int, len(addresses))
+
+	calls := []w3types.Caller{eth.CallFunc(c.contract, funcDecimals).AtBlock(blockNumber).Returns(&c.decimals)}
+	for i, address := range addresses {
+		calls = append(calls, eth.CallFunc(c.contract, funcBalanceOf, address).AtBlock(blockNumber).Returns(&c.balances[i]))
+	}
+	return calls
+}
+
+func (c *check) Decimals() uint8 {
+	if c.decimals == nil {
+		return 0
+	}
+	return *c.decimals
+}
+
+func (c *check) Results() ([]standard.Result, error) {
+	if c.decimals == nil {
+		return nil, errors.New("decimals not fetched")
+	}
+	threshold, err := c.condition.Convert(func(amount string) (*big.Int, error) {
+		return units.ParseDecimal(amount, *c.decimals)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]standard.Result, len(c.balances))
+	for i, balance := range c.balances {
+		results[i] = standard.Result{
+			Address:   c.addresses[i],
+			Balance:   balance,
+			Threshold: threshold,
+			Met:       threshold.Met(balance),
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package erc721
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var funcBalanceOf = w3.MustNewFunc("balanceOf(address)", "uint256")
+
+func init() {
+	standard.Register(Checker{})
+}
+
+// Checker checks that a wallet holds at least amount tokens of an ERC-721
+// collection, or compares the balance with a comparison operator such as
+// between:1:4. An amount such as ids:1,5,10-20 instead checks that a wallet
+// owns any of the listed token IDs, and traits:Rarity=Legendary that it owns
+// a token with all of the listed traits in its metadata.
+type Checker struct{}
+
+func (Checker) Names() []string {
+	return []string{"erc721", "nft"}
+}
+
+func (Checker) NewCheck(network, contract, amount string) (standard.Check, error) {
+	if contract == "" {
+		return nil, standard.ErrMissingContract
+	}
+	if selector, ok := strings.CutPrefix(amount, idsPrefix); ok {
+		ids, err := parseTokenIDs(selector)
+		if err != nil {
+			return nil, err
+		}
+		return &ownerCheck{contract: w3.A(contract), ids: ids}, nil
+	}
+	if filters, ok := strings.CutPrefix(amount, traitsPrefix); ok {
+		traits, err := parseTraits(filters)
+		if err != nil {
+			return nil, err
+		}
+		return &traitCheck{contract: w3.A(contract), traits: traits}, nil
+	}
+	condition, err := compare.Parse(amount)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := condition.Convert(compare.Integer)
+	if err != nil {
+		return nil, err
+	}
+	return &check{contract: w3.A(contract), threshold: threshold}, nil
+}
+
+type check struct {
+	contract  common.Address
+	threshold compare.Threshold
+	addresses []common.Address
+	balances  []*big.Int
+}
+
+func (c *check) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	c.addresses = addresses
+	c.balances = make([]*big.Int, len(addresses))
+
+	calls := make([]w3types.Caller, len(addresses))
+	for i, address := range addresses {
+		calls[i] = eth.CallFunc(c.contract, funcBalanceOf, address).AtBlock(blockNumber).Returns(&c.balances[i])
+	}
+	return calls
+}
+
+func (c *check) Results() ([]standard.Result, error) {
+	results := make([]standard.Result, len(c.balances))
+	for i, balance := range c.balances {
+		results[i] = standard.Result{
+			Address:   c.addresses[i],
+			Balance:   balance,
+			Threshold: c.threshold,
+			Met:       c.threshold.Met(balance),
+		}
+	}
+	return results, nil
+}
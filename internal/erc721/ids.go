@@ -0,0 +1,70 @@
+package erc721
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+)
+
+// idsPrefix marks an amount that selects token IDs instead of a number of
+// tokens, e.g. ids:42, ids:1,5,9 or ids:1-100.
+const idsPrefix = "ids:"
+
+var ErrInvalidTokenIDs = errors.New("token IDs must be ids: followed by IDs or ranges separated by commas, e.g. ids:1,5,10-20")
+
+// parseTokenIDs parses the selector after the ids: prefix. A range such as
+// 1-100 includes both ends; duplicates are checked once. The token IDs are
+// bounded by maxTokenIds of the limits configuration.
+func parseTokenIDs(selector string) ([]*big.Int, error) {
+	limits := shared.Limits()
+	var ids []*big.Int
+	seen := make(map[string]bool)
+	add := func(id *big.Int) error {
+		if seen[id.String()] {
+			return nil
+		}
+		if len(ids) == limits.MaxTokenIDs {
+			return limits.TokenIDs(len(ids) + 1)
+		}
+		seen[id.String()] = true
+		ids = append(ids, id)
+		return nil
+	}
+
+	for _, part := range strings.Split(selector, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := parseTokenID(first)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseTokenID(last); err != nil {
+				return nil, err
+			}
+			if start.Cmp(end) > 0 {
+				return nil, fmt.Errorf("%w: empty range %q", ErrInvalidTokenIDs, part)
+			}
+			if new(big.Int).Sub(end, start).Cmp(big.NewInt(int64(limits.MaxTokenIDs))) >= 0 {
+				return nil, fmt.Errorf("%q: %w", part, limits.TokenIDs(limits.MaxTokenIDs+1))
+			}
+		}
+		for id := new(big.Int).Set(start); id.Cmp(end) <= 0; id = new(big.Int).Add(id, big.NewInt(1)) {
+			if err := add(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ids, nil
+}
+
+func parseTokenID(s string) (*big.Int, error) {
+	id, ok := new(big.Int).SetString(s, 10)
+	if !ok || id.Sign() < 0 || strings.HasPrefix(s, "+") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTokenIDs, s)
+	}
+	return id, nil
+}
@@ -0,0 +1,51 @@
+package erc721
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenIDs(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected []int64
+		err      error
+	}{
+		{"42", []int64{42}, nil},
+		{"1,5,9", []int64{1, 5, 9}, nil},
+		{"1-3", []int64{1, 2, 3}, nil},
+		{"8-9,1,9", []int64{8, 9, 1}, nil},
+		{"0", []int64{0}, nil},
+		{"", nil, ErrInvalidTokenIDs},
+		{"1,", nil, ErrInvalidTokenIDs},
+		{"5-3", nil, ErrInvalidTokenIDs},
+		{"-1", nil, ErrInvalidTokenIDs},
+		{"+1", nil, ErrInvalidTokenIDs},
+		{"1-2-3", nil, ErrInvalidTokenIDs},
+		{"0x10", nil, ErrInvalidTokenIDs},
+		{"1-1000", nil, nil},
+		{"1-1001", nil, limits.ErrTooManyTokenIDs},
+		{"1-999,1000,1001", nil, limits.ErrTooManyTokenIDs},
+	}
+
+	for _, test := range tests {
+		ids, err := parseTokenIDs(test.selector)
+		if test.err != nil {
+			assert.True(t, errors.Is(err, test.err), "Selector %q should fail with %v, got %v", test.selector, test.err, err)
+			continue
+		}
+		assert.NoError(t, err, "Selector %q should parse", test.selector)
+		if test.expected == nil {
+			continue
+		}
+		expected := make([]*big.Int, len(test.expected))
+		for i, id := range test.expected {
+			expected[i] = big.NewInt(id)
+		}
+		assert.Equal(t, expected, ids, "IDs should match for %q", test.selector)
+	}
+}
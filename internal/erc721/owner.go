@@ -0,0 +1,76 @@
+package erc721
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var funcOwnerOf = w3.MustNewFunc("ownerOf(uint256)", "address")
+
+// ownerCheck checks that a wallet owns any of the selected token IDs. The
+// owner of every token is fetched once and compared with all addresses.
+type ownerCheck struct {
+	contract  common.Address
+	ids       []*big.Int
+	addresses []common.Address
+	outputs   [][]byte
+}
+
+func (c *ownerCheck) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	c.addresses = addresses
+	c.outputs = make([][]byte, len(c.ids))
+
+	calls := make([]w3types.Caller, len(c.ids))
+	for i, id := range c.ids {
+		msg := &w3types.Message{To: &c.contract, Func: funcOwnerOf, Args: []any{id}}
+		calls[i] = eth.Call(msg, blockNumber, nil).Returns(&c.outputs[i])
+	}
+	return calls
+}
+
+// AllowFailure allows every ownerOf call to revert, as it does for tokens
+// that were never minted or were burned. Such tokens are owned by nobody.
+func (c *ownerCheck) AllowFailure(int) bool {
+	return true
+}
+
+// Results returns a balance of 1 or 0 for every address and token ID. Tokens
+// whose ownerOf reverted or returned nothing, as on an address without code,
+// are owned by nobody.
+func (c *ownerCheck) Results() ([]standard.Result, error) {
+	owners := make([]common.Address, len(c.ids))
+	for i, output := range c.outputs {
+		if len(output) == 0 {
+			continue
+		}
+		if err := funcOwnerOf.DecodeReturns(output, &owners[i]); err != nil {
+			return nil, fmt.Errorf("ownerOf(%s): %w", c.ids[i], err)
+		}
+	}
+
+	threshold := compare.AtLeast(big.NewInt(1))
+	results := make([]standard.Result, 0, len(c.addresses)*len(c.ids))
+	for _, address := range c.addresses {
+		for i, id := range c.ids {
+			balance := new(big.Int)
+			if owners[i] == address && address != (common.Address{}) {
+				balance.SetInt64(1)
+			}
+			results = append(results, standard.Result{
+				Address:   address,
+				TokenID:   id,
+				Balance:   balance,
+				Threshold: threshold,
+				Met:       threshold.Met(balance),
+			})
+		}
+	}
+	return results, nil
+}
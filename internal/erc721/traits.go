@@ -0,0 +1,229 @@
+package erc721
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/metadata"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const (
+	// traitsPrefix marks an amount that filters tokens by their metadata,
+	// e.g. traits:Rarity=Legendary,Background=Gold.
+	traitsPrefix = "traits:"
+
+	// maxTraitTokens bounds the tokens enumerated by a single check, over
+	// all addresses.
+	maxTraitTokens = 100
+
+	// maxConcurrentFetches bounds the metadata fetches of a check in flight.
+	maxConcurrentFetches = 8
+)
+
+var (
+	funcSupportsInterface   = w3.MustNewFunc("supportsInterface(bytes4)", "bool")
+	funcTokenOfOwnerByIndex = w3.MustNewFunc("tokenOfOwnerByIndex(address,uint256)", "uint256")
+	funcTokenURI            = w3.MustNewFunc("tokenURI(uint256)", "string")
+
+	// interfaceEnumerable is the ERC-165 interface ID of ERC721Enumerable.
+	interfaceEnumerable = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+
+	ErrInvalidTraits   = errors.New("traits must be traits: followed by type=value pairs separated by commas, e.g. traits:Rarity=Legendary")
+	ErrNotEnumerable   = fmt.Errorf("%w: collection is not ERC721Enumerable", standard.ErrUnsupported)
+	ErrNoMetadataFetch = errors.New("metadata fetching is not configured")
+	ErrTooManyTokens   = fmt.Errorf("%w: wallets own more than %d tokens of the collection, too many to check for traits", standard.ErrUnsupported, maxTraitTokens)
+)
+
+// trait is a filter on one attribute of the token metadata.
+type trait struct {
+	traitType string
+	value     string
+}
+
+func parseTraits(filters string) ([]trait, error) {
+	var traits []trait
+	for _, filter := range strings.Split(filters, ",") {
+		traitType, value, ok := strings.Cut(filter, "=")
+		traitType, value = strings.TrimSpace(traitType), strings.TrimSpace(value)
+		if !ok || traitType == "" || value == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTraits, filter)
+		}
+		traits = append(traits, trait{traitType: traitType, value: value})
+	}
+	return traits, nil
+}
+
+// ownedToken is a token enumerated from the wallet at addresses[owner].
+type ownedToken struct {
+	owner   int
+	id      *big.Int
+	uri     string
+	matched bool
+}
+
+// traitCheck checks that a wallet owns a token whose metadata has all of the
+// traits. Tokens are enumerated with tokenOfOwnerByIndex, so the collection
+// must be ERC721Enumerable. The stages are:
+//
+//  0. supportsInterface and balanceOf of every address
+//  1. tokenOfOwnerByIndex for up to maxTraitTokens tokens
+//  2. tokenURI of every token
+//  3. the metadata of every token, fetched over HTTP(S) or IPFS
+type traitCheck struct {
+	contract    common.Address
+	traits      []trait
+	blockNumber *big.Int
+	stage       int
+
+	enumerable bool
+	addresses  []common.Address
+	balances   []*big.Int
+	tokens     []*ownedToken
+	truncated  bool
+}
+
+func (c *traitCheck) Selective() {}
+
+func (c *traitCheck) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	c.addresses = addresses
+	c.blockNumber = blockNumber
+	c.balances = make([]*big.Int, len(addresses))
+	c.stage = 0
+
+	calls := []w3types.Caller{
+		eth.CallFunc(c.contract, funcSupportsInterface, interfaceEnumerable).AtBlock(blockNumber).Returns(&c.enumerable),
+	}
+	for i, address := range addresses {
+		calls = append(calls, eth.CallFunc(c.contract, funcBalanceOf, address).AtBlock(blockNumber).Returns(&c.balances[i]))
+	}
+	return calls
+}
+
+// AllowFailure allows supportsInterface to revert on collections without
+// ERC-165, and tokenURI to revert on tokens without metadata.
+func (c *traitCheck) AllowFailure(i int) bool {
+	return c.stage == 0 && i == 0 || c.stage == 2
+}
+
+func (c *traitCheck) Next(ctx context.Context) ([]w3types.Caller, error) {
+	c.stage++
+	switch c.stage {
+	case 1:
+		return c.enumerate()
+	case 2:
+		calls := make([]w3types.Caller, len(c.tokens))
+		for i, token := range c.tokens {
+			calls[i] = eth.CallFunc(c.contract, funcTokenURI, token.id).AtBlock(c.blockNumber).Returns(&token.uri)
+		}
+		return calls, nil
+	case 3:
+		return nil, c.match(ctx)
+	}
+	return nil, nil
+}
+
+func (c *traitCheck) enumerate() ([]w3types.Caller, error) {
+	var calls []w3types.Caller
+	for i, balance := range c.balances {
+		if balance == nil || balance.Sign() == 0 {
+			continue
+		}
+		if !c.enumerable {
+			return nil, ErrNotEnumerable
+		}
+		for index := int64(0); balance.Cmp(big.NewInt(index)) > 0; index++ {
+			if len(c.tokens) == maxTraitTokens {
+				c.truncated = true
+				return calls, nil
+			}
+			token := &ownedToken{owner: i}
+			c.tokens = append(c.tokens, token)
+			calls = append(calls, eth.CallFunc(c.contract, funcTokenOfOwnerByIndex, c.addresses[i], big.NewInt(index)).AtBlock(c.blockNumber).Returns(&token.id))
+		}
+	}
+	return calls, nil
+}
+
+// match fetches the metadata of every token and marks the tokens that have
+// all traits. Tokens whose metadata cannot be fetched do not match.
+func (c *traitCheck) match(ctx context.Context) error {
+	fetcher := shared.Metadata
+	if fetcher == nil {
+		return ErrNoMetadataFetch
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentFetches)
+	for _, token := range c.tokens {
+		if token.uri == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(token *ownedToken) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			md, err := fetcher.Fetch(ctx, token.uri)
+			if err != nil {
+				log.Printf("Metadata of token %s of %s: %v", token.id, c.contract, err)
+				return
+			}
+			token.matched = c.matches(md)
+		}(token)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (c *traitCheck) matches(md metadata.Metadata) bool {
+	for _, t := range c.traits {
+		if !md.Has(t.traitType, t.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Results returns the number of matching tokens of every address. If not all
+// tokens were enumerated and none of them matched, the unchecked tokens might
+// have, so the check fails with ErrTooManyTokens rather than report no match.
+func (c *traitCheck) Results() ([]standard.Result, error) {
+	threshold := compare.AtLeast(big.NewInt(1))
+	counts := make([]*big.Int, len(c.addresses))
+	for i := range counts {
+		counts[i] = new(big.Int)
+	}
+	for _, token := range c.tokens {
+		if token.matched {
+			counts[token.owner].Add(counts[token.owner], big.NewInt(1))
+		}
+	}
+
+	results := make([]standard.Result, len(c.addresses))
+	met := false
+	for i, address := range c.addresses {
+		results[i] = standard.Result{
+			Address:   address,
+			Balance:   counts[i],
+			Threshold: threshold,
+			Met:       threshold.Met(counts[i]),
+		}
+		met = met || results[i].Met
+	}
+	if c.truncated && !met {
+		return nil, ErrTooManyTokens
+	}
+	return results, nil
+}
@@ -0,0 +1,93 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	DefaultMaxTokenIDs   = 1000
+	DefaultMaxWallets    = 50
+	DefaultMaxCalls      = 500
+	DefaultBatchSize     = 500
+	DefaultRPCBatchSize  = 100
+	DefaultRPCConcurrent = 4
+)
+
+var (
+	ErrTooManyTokenIDs = errors.New("too many token IDs")
+	ErrTooManyWallets  = errors.New("too many wallets")
+	ErrTooManyCalls    = errors.New("request needs too many RPC calls")
+)
+
+// Config is the limits section of configuration.json. Zero values use the
+// defaults.
+//
+// MaxTokenIDs bounds the token IDs of a check after ranges are expanded,
+// MaxWallets the wallets of a request and MaxCalls the eth_calls a request
+// sends over all networks. Larger requests are refused. BatchSize is the
+// number of (wallet, token ID) pairs of a single balanceOfBatch; larger
+// checks are split into several. The calls of a network are sent in JSON-RPC
+// batches of RPCBatchSize calls, RPCConcurrent of them at a time.
+type Config struct {
+	MaxTokenIDs   int `json:"maxTokenIds"`
+	MaxWallets    int `json:"maxWallets"`
+	MaxCalls      int `json:"maxCalls"`
+	BatchSize     int `json:"batchSize"`
+	RPCBatchSize  int `json:"rpcBatchSize"`
+	RPCConcurrent int `json:"rpcConcurrent"`
+}
+
+// WithDefaults returns the config with the defaults in place of zero values.
+func (c Config) WithDefaults() Config {
+	set := func(value *int, def int) {
+		if *value <= 0 {
+			*value = def
+		}
+	}
+	set(&c.MaxTokenIDs, DefaultMaxTokenIDs)
+	set(&c.MaxWallets, DefaultMaxWallets)
+	set(&c.MaxCalls, DefaultMaxCalls)
+	set(&c.BatchSize, DefaultBatchSize)
+	set(&c.RPCBatchSize, DefaultRPCBatchSize)
+	set(&c.RPCConcurrent, DefaultRPCConcurrent)
+	return c
+}
+
+// TokenIDs returns an error if count token IDs exceed the limit.
+func (c Config) TokenIDs(count int) error {
+	if count > c.MaxTokenIDs {
+		return fmt.Errorf("%w: at most %d token IDs can be checked at once", ErrTooManyTokenIDs, c.MaxTokenIDs)
+	}
+	return nil
+}
+
+// Wallets returns an error if count wallets exceed the limit.
+func (c Config) Wallets(count int) error {
+	if count > c.MaxWallets {
+		return fmt.Errorf("%w: at most %d wallets can be checked at once, got %d", ErrTooManyWallets, c.MaxWallets, count)
+	}
+	return nil
+}
+
+type callsKey struct{}
+
+// WithCallBudget returns a context allowing MaxCalls calls, spent with
+// SpendCalls by every batch of the request.
+func (c Config) WithCallBudget(ctx context.Context) context.Context {
+	budget := new(atomic.Int64)
+	budget.Store(int64(c.MaxCalls))
+	return context.WithValue(ctx, callsKey{}, budget)
+}
+
+// SpendCalls spends n calls of the budget of ctx. Contexts without a budget
+// are not limited.
+func SpendCalls(ctx context.Context, n int) error {
+	budget, ok := ctx.Value(callsKey{}).(*atomic.Int64)
+	if ok && budget.Add(-int64(n)) < 0 {
+		return ErrTooManyCalls
+	}
+	return nil
+}
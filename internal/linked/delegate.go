@@ -0,0 +1,157 @@
+package linked
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/revert"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const TypeDelegate = "delegate"
+
+// Delegation types of the delegate.xyz v2 registry.
+const (
+	delegationAll      = 1
+	delegationContract = 2
+	delegationERC721   = 3
+	delegationERC20    = 4
+	delegationERC1155  = 5
+)
+
+var (
+	// RegistryDelegate is the delegate.xyz v2 registry, deployed at the
+	// same address on every chain.
+	RegistryDelegate = w3.A("0x00000000000000447e69651d841bD8D104Bed493")
+
+	funcGetIncomingDelegations = w3.MustNewFunc("getIncomingDelegations(address to)", "(uint8 kind, address to, address from, bytes32 rights, address contract, uint256 tokenId, uint256 amount)[] delegations")
+	funcOwnerOf                = w3.MustNewFunc("ownerOf(uint256)", "address")
+)
+
+func init() {
+	Register(TypeDelegate, newDelegateResolver)
+}
+
+// delegation is a Delegation of the delegate.xyz v2 registry.
+type delegation struct {
+	Kind     uint8
+	To       common.Address
+	From     common.Address
+	Rights   [32]byte
+	Contract common.Address
+	TokenId  *big.Int
+	Amount   *big.Int
+}
+
+// delegateResolver resolves the vaults that delegated to a wallet in the
+// delegate.xyz v2 registry. Wallet delegations count in full; contract,
+// token and amount delegations are scoped to their contract, token and
+// amount. Delegations with specific rights are used only if they have the
+// configured rights.
+type delegateResolver struct {
+	registry common.Address
+	rights   [32]byte
+}
+
+func newDelegateResolver(config Config) (LinkedAccountResolver, error) {
+	r := &delegateResolver{registry: RegistryDelegate}
+	if config.Registry != "" {
+		registry, err := address(TypeDelegate, "registry", config.Registry)
+		if err != nil {
+			return nil, err
+		}
+		r.registry = registry
+	}
+	if config.Rights != "" {
+		rights, err := uint256(TypeDelegate, "rights", config.Rights)
+		if err != nil {
+			return nil, err
+		}
+		r.rights = common.BigToHash(rights)
+	}
+	return r, nil
+}
+
+func (r *delegateResolver) Source() string {
+	return TypeDelegate
+}
+
+// Resolve reads the incoming delegations of every owner, then confirms that
+// the vaults of ERC-721 token delegations still own their token.
+func (r *delegateResolver) Resolve(ctx context.Context, client Client, owners []common.Address, blockNumber *big.Int) ([]Account, error) {
+	delegations := make([][]delegation, len(owners))
+	calls := make([]w3types.Caller, len(owners))
+	for i, owner := range owners {
+		calls[i] = eth.CallFunc(r.registry, funcGetIncomingDelegations, owner).AtBlock(blockNumber).Returns(&delegations[i])
+	}
+	if err := call(ctx, client, calls); err != nil {
+		return nil, err
+	}
+
+	var accounts []Account
+	var tokens []int
+	for i, owner := range owners {
+		for _, d := range delegations[i] {
+			if d.Rights != ([32]byte{}) && d.Rights != r.rights {
+				continue
+			}
+			account := Account{Address: d.From, Owner: owner, Source: TypeDelegate}
+			switch d.Kind {
+			case delegationAll:
+			case delegationContract:
+				account.Scope = &Scope{Contract: d.Contract}
+			case delegationERC721:
+				account.Scope = &Scope{Contract: d.Contract, TokenID: d.TokenId, Amount: big.NewInt(1)}
+				tokens = append(tokens, len(accounts))
+			case delegationERC20:
+				account.Scope = &Scope{Contract: d.Contract, Amount: d.Amount}
+			case delegationERC1155:
+				account.Scope = &Scope{Contract: d.Contract, TokenID: d.TokenId, Amount: d.Amount}
+			default:
+				continue
+			}
+			accounts = append(accounts, account)
+		}
+	}
+	if len(tokens) == 0 {
+		return accounts, nil
+	}
+
+	// Delegations of tokens the vault no longer owns are left in the
+	// registry, so the owner is checked. Tokens whose ownerOf reverts or
+	// returns nothing are owned by nobody; other failures are returned, as
+	// they say nothing about the token.
+	outputs := make([][]byte, len(tokens))
+	calls = calls[:0]
+	for i, index := range tokens {
+		scope := accounts[index].Scope
+		msg := &w3types.Message{To: &scope.Contract, Func: funcOwnerOf, Args: []any{scope.TokenID}}
+		calls = append(calls, eth.Call(msg, blockNumber, nil).Returns(&outputs[i]))
+	}
+	if err := call(ctx, client, calls); err != nil && !revert.Only(err) {
+		return nil, err
+	}
+	stale := make(map[int]bool)
+	for i, index := range tokens {
+		var owner common.Address
+		if len(outputs[i]) > 0 {
+			if err := funcOwnerOf.DecodeReturns(outputs[i], &owner); err != nil {
+				return nil, fmt.Errorf("ownerOf(%s) of %s: %w", accounts[index].Scope.TokenID, accounts[index].Scope.Contract, err)
+			}
+		}
+		if owner != accounts[index].Address {
+			stale[index] = true
+		}
+	}
+	owned := accounts[:0]
+	for i, account := range accounts {
+		if !stale[i] {
+			owned = append(owned, account)
+		}
+	}
+	return owned, nil
+}
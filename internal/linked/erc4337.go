@@ -0,0 +1,63 @@
+package linked
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const TypeERC4337 = "erc4337"
+
+var funcGetAddress = w3.MustNewFunc("getAddress(address owner, uint256 salt)", "address")
+
+func init() {
+	Register(TypeERC4337, newERC4337Resolver)
+}
+
+// erc4337Resolver resolves the smart account of every wallet deployed, or to
+// be deployed, by an ERC-4337 account factory with the getAddress(owner,
+// salt) function of the SimpleAccountFactory. Counterfactual accounts are
+// included, as they can hold tokens before they are deployed.
+type erc4337Resolver struct {
+	factory common.Address
+	salt    *big.Int
+}
+
+func newERC4337Resolver(config Config) (LinkedAccountResolver, error) {
+	factory, err := address(TypeERC4337, "factory", config.Factory)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := uint256(TypeERC4337, "salt", config.Salt)
+	if err != nil {
+		return nil, err
+	}
+	return &erc4337Resolver{factory: factory, salt: salt}, nil
+}
+
+func (r *erc4337Resolver) Source() string {
+	return TypeERC4337
+}
+
+func (r *erc4337Resolver) Resolve(ctx context.Context, client Client, owners []common.Address, blockNumber *big.Int) ([]Account, error) {
+	accounts := make([]common.Address, len(owners))
+	calls := make([]w3types.Caller, len(owners))
+	for i, owner := range owners {
+		calls[i] = eth.CallFunc(r.factory, funcGetAddress, owner, r.salt).AtBlock(blockNumber).Returns(&accounts[i])
+	}
+	if err := call(ctx, client, calls); err != nil {
+		return nil, err
+	}
+
+	var resolved []Account
+	for i, account := range accounts {
+		if account != (common.Address{}) {
+			resolved = append(resolved, Account{Address: account, Owner: owners[i], Source: TypeERC4337})
+		}
+	}
+	return resolved, nil
+}
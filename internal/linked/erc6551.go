@@ -0,0 +1,123 @@
+package linked
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const (
+	TypeERC6551 = "erc6551"
+
+	// maxBoundTokens bounds the tokens of a wallet whose accounts are
+	// resolved.
+	maxBoundTokens = 20
+)
+
+var (
+	// RegistryERC6551 is the canonical ERC-6551 registry.
+	RegistryERC6551 = w3.A("0x000000006551c19487814612e58FE06813775758")
+
+	funcBalanceOf           = w3.MustNewFunc("balanceOf(address)", "uint256")
+	funcTokenOfOwnerByIndex = w3.MustNewFunc("tokenOfOwnerByIndex(address,uint256)", "uint256")
+	funcAccount             = w3.MustNewFunc("account(address implementation, bytes32 salt, uint256 chainId, address tokenContract, uint256 tokenId)", "address")
+)
+
+func init() {
+	Register(TypeERC6551, newERC6551Resolver)
+}
+
+// erc6551Resolver resolves the token bound accounts of the tokens a wallet
+// holds in an ERC721Enumerable collection. Only the first maxBoundTokens
+// tokens of every wallet are considered.
+type erc6551Resolver struct {
+	registry       common.Address
+	implementation common.Address
+	tokenContract  common.Address
+	chainID        *big.Int
+	salt           common.Hash
+}
+
+func newERC6551Resolver(config Config) (LinkedAccountResolver, error) {
+	r := &erc6551Resolver{registry: RegistryERC6551}
+	var err error
+	if config.Registry != "" {
+		if r.registry, err = address(TypeERC6551, "registry", config.Registry); err != nil {
+			return nil, err
+		}
+	}
+	if r.implementation, err = address(TypeERC6551, "implementation", config.Implementation); err != nil {
+		return nil, err
+	}
+	if r.tokenContract, err = address(TypeERC6551, "tokenContract", config.TokenContract); err != nil {
+		return nil, err
+	}
+	if config.ChainID == 0 {
+		return nil, fmt.Errorf("%w: %s needs the chainId of the collection", ErrInvalidConfig, TypeERC6551)
+	}
+	r.chainID = new(big.Int).SetUint64(config.ChainID)
+	salt, err := uint256(TypeERC6551, "salt", config.Salt)
+	if err != nil {
+		return nil, err
+	}
+	r.salt = common.BigToHash(salt)
+	return r, nil
+}
+
+func (r *erc6551Resolver) Source() string {
+	return TypeERC6551
+}
+
+// Resolve enumerates the tokens of every owner and asks the registry for
+// their accounts, in three batches.
+func (r *erc6551Resolver) Resolve(ctx context.Context, client Client, owners []common.Address, blockNumber *big.Int) ([]Account, error) {
+	balances := make([]*big.Int, len(owners))
+	calls := make([]w3types.Caller, len(owners))
+	for i, owner := range owners {
+		calls[i] = eth.CallFunc(r.tokenContract, funcBalanceOf, owner).AtBlock(blockNumber).Returns(&balances[i])
+	}
+	if err := call(ctx, client, calls); err != nil {
+		return nil, err
+	}
+
+	type token struct {
+		owner common.Address
+		id    *big.Int
+	}
+	var tokens []*token
+	calls = calls[:0]
+	for i, owner := range owners {
+		count := maxBoundTokens
+		if balances[i].IsInt64() && balances[i].Int64() < maxBoundTokens {
+			count = int(balances[i].Int64())
+		}
+		for index := 0; index < count; index++ {
+			t := &token{owner: owner}
+			tokens = append(tokens, t)
+			calls = append(calls, eth.CallFunc(r.tokenContract, funcTokenOfOwnerByIndex, owner, big.NewInt(int64(index))).AtBlock(blockNumber).Returns(&t.id))
+		}
+	}
+	if err := call(ctx, client, calls); err != nil {
+		return nil, err
+	}
+
+	accounts := make([]common.Address, len(tokens))
+	calls = calls[:0]
+	for i, t := range tokens {
+		calls = append(calls, eth.CallFunc(r.registry, funcAccount, r.implementation, r.salt, r.chainID, r.tokenContract, t.id).AtBlock(blockNumber).Returns(&accounts[i]))
+	}
+	if err := call(ctx, client, calls); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]Account, len(tokens))
+	for i, t := range tokens {
+		resolved[i] = Account{Address: accounts[i], Owner: t.owner, Source: TypeERC6551}
+	}
+	return resolved, nil
+}
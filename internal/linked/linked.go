@@ -0,0 +1,213 @@
+package linked
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// LinkedAccountResolver finds the accounts linked to the wallets of a
+// request, such as smart accounts they control. The balances of the linked
+// accounts count for the wallets on the network of the resolver.
+type LinkedAccountResolver interface {
+	// Source names the kind of the resolved accounts, such as "futurepass".
+	// It is the source of their entries in verbose reports.
+	Source() string
+
+	// Resolve returns the accounts linked to the owners at blockNumber, or
+	// at the latest block if blockNumber is nil.
+	Resolve(ctx context.Context, client Client, owners []common.Address, blockNumber *big.Int) ([]Account, error)
+}
+
+// Client is the subset of the RPC client of a network used by resolvers.
+type Client interface {
+	CallCtx(ctx context.Context, calls ...w3types.Caller) error
+}
+
+// Account is a resolved account and the wallet it is linked to. An account
+// with a Scope counts only within it; otherwise it counts in full.
+type Account struct {
+	Address common.Address
+	Owner   common.Address
+	Source  string
+	Scope   *Scope
+}
+
+// Scope limits the balances an account counts with to a contract and
+// optionally to a token ID of it and an amount. An account may have several
+// scopes, whose amounts add up.
+type Scope struct {
+	Contract common.Address
+	TokenID  *big.Int
+	Amount   *big.Int
+}
+
+// MarshalJSON writes the addresses checksummed, like the rest of the
+// response.
+func (a Account) MarshalJSON() ([]byte, error) {
+	type scope struct {
+		Contract string `json:"contract"`
+		TokenID  string `json:"tokenId,omitempty"`
+		Amount   string `json:"amount,omitempty"`
+	}
+	account := struct {
+		Address string `json:"address"`
+		Owner   string `json:"owner"`
+		Source  string `json:"source"`
+		Scope   *scope `json:"scope,omitempty"`
+	}{Address: a.Address.Hex(), Owner: a.Owner.Hex(), Source: a.Source}
+	if a.Scope != nil {
+		account.Scope = &scope{Contract: a.Scope.Contract.Hex()}
+		if a.Scope.TokenID != nil {
+			account.Scope.TokenID = a.Scope.TokenID.String()
+		}
+		if a.Scope.Amount != nil {
+			account.Scope.Amount = a.Scope.Amount.String()
+		}
+	}
+	return json.Marshal(account)
+}
+
+// Allowance returns the part of the balance of a scoped account in contract
+// that counts. Scopes of other contracts allow nothing, and scopes of a token
+// ID allow nothing for other token IDs. Balances without a token ID, such as
+// ERC-721 balances, are allowed the amount of every scope of the contract;
+// see WithoutTokenIDs for balances that count only some tokens.
+func Allowance(scopes []Scope, contract common.Address, tokenID *big.Int, balance *big.Int) *big.Int {
+	if balance == nil {
+		return new(big.Int)
+	}
+	allowed := new(big.Int)
+	for _, scope := range scopes {
+		if scope.Contract != contract {
+			continue
+		}
+		if scope.TokenID != nil && tokenID != nil && scope.TokenID.Cmp(tokenID) != 0 {
+			continue
+		}
+		if scope.Amount == nil {
+			return new(big.Int).Set(balance)
+		}
+		allowed.Add(allowed, scope.Amount)
+	}
+	if allowed.Cmp(balance) > 0 {
+		return new(big.Int).Set(balance)
+	}
+	return allowed
+}
+
+// WithoutTokenIDs returns the scopes that are not limited to a token ID.
+func WithoutTokenIDs(scopes []Scope) []Scope {
+	var filtered []Scope
+	for _, scope := range scopes {
+		if scope.TokenID == nil {
+			filtered = append(filtered, scope)
+		}
+	}
+	return filtered
+}
+
+// Config configures one resolver of a network in the linkedAccounts section
+// of configuration.json. Type selects the resolver; which of the other
+// fields apply depends on it.
+type Config struct {
+	Type           string `json:"type"`
+	Registry       string `json:"registry"`
+	Factory        string `json:"factory"`
+	Implementation string `json:"implementation"`
+	TokenContract  string `json:"tokenContract"`
+	ChainID        uint64 `json:"chainId"`
+	Salt           string `json:"salt"`
+	Rights         string `json:"rights"`
+	CacheTTL       int    `json:"cacheTTL"`
+}
+
+// Factory returns the resolver for a config.
+type Factory func(config Config) (LinkedAccountResolver, error)
+
+var (
+	ErrUnknownType      = errors.New("unknown linked account type")
+	ErrInvalidConfig    = errors.New("invalid linked account config")
+	ErrResolutionFailed = errors.New("linked account resolution failed")
+)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a resolver available under a type. It is meant to be called
+// from the init function of the package implementing the resolver and panics
+// if the type is already registered.
+func Register(kind string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := factories[kind]; exists {
+		panic(fmt.Sprintf("linked: %s registered twice", kind))
+	}
+	factories[kind] = factory
+}
+
+// Types returns the registered types.
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New returns the resolver of the type of config.
+func New(config Config) (LinkedAccountResolver, error) {
+	mu.RLock()
+	factory, ok := factories[config.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, expected one of %v", ErrUnknownType, config.Type, Types())
+	}
+	return factory(config)
+}
+
+// call sends the calls in one batch, spending them from the call budget of
+// the request.
+func call(ctx context.Context, client Client, calls []w3types.Caller) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	if err := limits.SpendCalls(ctx, len(calls)); err != nil {
+		return err
+	}
+	return client.CallCtx(ctx, calls...)
+}
+
+// address parses a required address field of a config.
+func address(kind, field, value string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("%w: %s needs %s to be an address, got %q", ErrInvalidConfig, kind, field, value)
+	}
+	return common.HexToAddress(value), nil
+}
+
+// uint256 parses a uint256 field of a config, given as a decimal or
+// 0x-prefixed hexadecimal number, that defaults to 0.
+func uint256(kind, field, value string) (*big.Int, error) {
+	if value == "" {
+		return new(big.Int), nil
+	}
+	n, ok := new(big.Int).SetString(value, 0)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s %s must be a uint256, got %q", ErrInvalidConfig, kind, field, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,71 @@
+package linked
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	const address = "0x00000000000000000000000000000000000000F0"
+	tests := []struct {
+		name   string
+		config Config
+		err    error
+	}{
+		{"erc4337", Config{Type: TypeERC4337, Factory: address}, nil},
+		{"erc4337 with hex salt", Config{Type: TypeERC4337, Factory: address, Salt: "0x2a"}, nil},
+		{"erc4337 without factory", Config{Type: TypeERC4337}, ErrInvalidConfig},
+		{"erc4337 with negative salt", Config{Type: TypeERC4337, Factory: address, Salt: "-1"}, ErrInvalidConfig},
+		{"erc6551", Config{Type: TypeERC6551, Implementation: address, TokenContract: address, ChainID: 1}, nil},
+		{"erc6551 without chain ID", Config{Type: TypeERC6551, Implementation: address, TokenContract: address}, ErrInvalidConfig},
+		{"erc6551 with invalid registry", Config{Type: TypeERC6551, Registry: "0x1", Implementation: address, TokenContract: address, ChainID: 1}, ErrInvalidConfig},
+		{"safe", Config{Type: TypeSafe, Registry: "https://safe-transaction-mainnet.safe.global/"}, nil},
+		{"safe with address as registry", Config{Type: TypeSafe, Registry: address}, ErrInvalidConfig},
+		{"delegate", Config{Type: TypeDelegate}, nil},
+		{"delegate with rights", Config{Type: TypeDelegate, Rights: "0x01"}, nil},
+		{"delegate with invalid rights", Config{Type: TypeDelegate, Rights: "all"}, ErrInvalidConfig},
+		{"unknown type", Config{Type: "eoa"}, ErrUnknownType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver, err := New(test.config)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.config.Type, resolver.Source())
+		})
+	}
+}
+
+func TestAllowance(t *testing.T) {
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000C0")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000C1")
+	tests := []struct {
+		name    string
+		scopes  []Scope
+		tokenID *big.Int
+		balance int64
+		allowed int64
+	}{
+		{"contract", []Scope{{Contract: contract}}, nil, 50, 50},
+		{"other contract", []Scope{{Contract: other}}, nil, 50, 0},
+		{"amount", []Scope{{Contract: contract, Amount: big.NewInt(10)}}, nil, 50, 10},
+		{"amount above balance", []Scope{{Contract: contract, Amount: big.NewInt(100)}}, nil, 50, 50},
+		{"amounts add up", []Scope{{Contract: contract, Amount: big.NewInt(10)}, {Contract: contract, Amount: big.NewInt(15)}}, nil, 50, 25},
+		{"token", []Scope{{Contract: contract, TokenID: big.NewInt(7), Amount: big.NewInt(2)}}, big.NewInt(7), 5, 2},
+		{"other token", []Scope{{Contract: contract, TokenID: big.NewInt(7), Amount: big.NewInt(2)}}, big.NewInt(8), 5, 0},
+		{"token in balance without token ID", []Scope{{Contract: contract, TokenID: big.NewInt(7), Amount: big.NewInt(1)}}, nil, 5, 1},
+		{"contract and amount", []Scope{{Contract: contract, Amount: big.NewInt(10)}, {Contract: contract}}, nil, 50, 50},
+	}
+
+	for _, test := range tests {
+		allowed := Allowance(test.scopes, contract, test.tokenID, big.NewInt(test.balance))
+		assert.Equal(t, test.allowed, allowed.Int64(), test.name)
+	}
+}
@@ -0,0 +1,170 @@
+package linked
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/revert"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const (
+	TypeSafe = "safe"
+
+	// maxSafes bounds the Safes of a wallet that are checked.
+	maxSafes = 20
+
+	// maxConcurrentLookups bounds the registry lookups in flight.
+	maxConcurrentLookups = 8
+
+	safeTimeout  = 5 * time.Second
+	safeMaxBytes = 1 << 20
+)
+
+var (
+	funcIsOwner      = w3.MustNewFunc("isOwner(address)", "bool")
+	funcGetThreshold = w3.MustNewFunc("getThreshold()", "uint256")
+)
+
+func init() {
+	Register(TypeSafe, newSafeResolver)
+}
+
+// safeResolver resolves the Safes owned by a wallet. The registry is a Safe
+// Transaction Service, e.g. https://safe-transaction-mainnet.safe.global,
+// which indexes Safes by owner. As the index may lag or be wrong, every Safe
+// it lists is confirmed with isOwner on chain, at the block of the request.
+// Only Safes with a threshold of 1 are resolved: the co-signers of an M-of-N
+// Safe cannot move its assets alone, so none of them is credited with them.
+type safeResolver struct {
+	registry string
+	client   *http.Client
+}
+
+func newSafeResolver(config Config) (LinkedAccountResolver, error) {
+	u, err := url.Parse(config.Registry)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s needs registry to be the URL of a Safe Transaction Service, got %q", ErrInvalidConfig, TypeSafe, config.Registry)
+	}
+	return &safeResolver{
+		registry: strings.TrimSuffix(config.Registry, "/"),
+		client:   &http.Client{Timeout: safeTimeout},
+	}, nil
+}
+
+func (r *safeResolver) Source() string {
+	return TypeSafe
+}
+
+func (r *safeResolver) Resolve(ctx context.Context, client Client, owners []common.Address, blockNumber *big.Int) ([]Account, error) {
+	candidates := make([][]common.Address, len(owners))
+	errs := make([]error, len(owners))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentLookups)
+	for i, owner := range owners {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, owner common.Address) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			candidates[i], errs[i] = r.safesOf(ctx, owner)
+		}(i, owner)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var safes []Account
+	for i, owner := range owners {
+		for _, safe := range candidates[i] {
+			safes = append(safes, Account{Address: safe, Owner: owner, Source: TypeSafe})
+		}
+	}
+	ownerOutputs := make([][]byte, len(safes))
+	thresholdOutputs := make([][]byte, len(safes))
+	calls := make([]w3types.Caller, 0, 2*len(safes))
+	for i, safe := range safes {
+		calls = append(calls,
+			eth.Call(&w3types.Message{To: &safes[i].Address, Func: funcIsOwner, Args: []any{safe.Owner}}, blockNumber, nil).Returns(&ownerOutputs[i]),
+			eth.Call(&w3types.Message{To: &safes[i].Address, Func: funcGetThreshold}, blockNumber, nil).Returns(&thresholdOutputs[i]),
+		)
+	}
+	// Safes deployed after the block have no code at it, so their calls
+	// revert or return nothing; they are not owned at it. Other failures
+	// are returned, as they say nothing about the Safe.
+	if err := call(ctx, client, calls); err != nil && !revert.Only(err) {
+		return nil, err
+	}
+
+	var resolved []Account
+	for i, safe := range safes {
+		if len(ownerOutputs[i]) == 0 || len(thresholdOutputs[i]) == 0 {
+			continue
+		}
+		var isOwner bool
+		threshold := new(big.Int)
+		if err := funcIsOwner.DecodeReturns(ownerOutputs[i], &isOwner); err != nil {
+			return nil, fmt.Errorf("isOwner of Safe %s: %w", safe.Address.Hex(), err)
+		}
+		if err := funcGetThreshold.DecodeReturns(thresholdOutputs[i], threshold); err != nil {
+			return nil, fmt.Errorf("getThreshold of Safe %s: %w", safe.Address.Hex(), err)
+		}
+		if isOwner && threshold.IsInt64() && threshold.Int64() == 1 {
+			resolved = append(resolved, safe)
+		}
+	}
+	return resolved, nil
+}
+
+// safesOf asks the registry for the Safes of an owner.
+func (r *safeResolver) safesOf(ctx context.Context, owner common.Address) ([]common.Address, error) {
+	target := fmt.Sprintf("%s/api/v1/owners/%s/safes/", r.registry, owner.Hex())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// The service answers 404 for addresses it has never seen.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("safe registry returned %s for %s", resp.Status, owner.Hex())
+	}
+
+	var body struct {
+		Safes []string `json:"safes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, safeMaxBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("safe registry: %w", err)
+	}
+	var safes []common.Address
+	for _, safe := range body.Safes {
+		if len(safes) == maxSafes {
+			break
+		}
+		if common.IsHexAddress(safe) {
+			safes = append(safes, common.HexToAddress(safe))
+		}
+	}
+	return safes, nil
+}
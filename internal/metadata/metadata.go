@@ -0,0 +1,334 @@
+package metadata
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultIPFSGateway = "https://ipfs.io/ipfs/"
+	DefaultTimeout     = 5
+	DefaultMaxFetches  = 50
+	DefaultMaxBytes    = 1 << 20
+	DefaultCacheTTL    = 3600
+	DefaultCacheSize   = 10000
+)
+
+var (
+	ErrUnsupportedURI  = errors.New("token URI must be http(s), ipfs or data")
+	ErrBudgetExhausted = errors.New("metadata fetch budget of the request is exhausted")
+	ErrPrivateAddress  = errors.New("metadata host resolves to a private address")
+	ErrTooLarge        = errors.New("metadata is too large")
+)
+
+// Config is the metadata section of configuration.json. Zero values use the
+// defaults. Timeout applies to a single fetch and CacheTTL to a cached
+// document, both in seconds. MaxFetches bounds the uncached fetches of a
+// request and MaxBytes the size of a document. Token URIs pointing to
+// loopback or private addresses are refused unless AllowPrivate is set.
+type Config struct {
+	IPFSGateway  string `json:"ipfsGateway"`
+	Timeout      int    `json:"timeout"`
+	MaxFetches   int    `json:"maxFetches"`
+	MaxBytes     int64  `json:"maxBytes"`
+	CacheTTL     int    `json:"cacheTTL"`
+	CacheSize    int    `json:"cacheSize"`
+	AllowPrivate bool   `json:"allowPrivate"`
+}
+
+// Attribute is one trait of a token, such as {"trait_type": "Rarity",
+// "value": "Legendary"}. Value is formatted as a string.
+type Attribute struct {
+	TraitType string
+	Value     string
+}
+
+// Metadata is the part of an ERC-721 metadata document used for gating.
+type Metadata struct {
+	Name       string
+	Attributes []Attribute
+}
+
+// Fetcher fetches and caches token metadata.
+type Fetcher struct {
+	config Config
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	metadata Metadata
+	expires  time.Time
+}
+
+// New returns a fetcher for the config.
+func New(config Config) (*Fetcher, error) {
+	if config.IPFSGateway == "" {
+		config.IPFSGateway = DefaultIPFSGateway
+	}
+	if !strings.HasSuffix(config.IPFSGateway, "/") {
+		config.IPFSGateway += "/"
+	}
+	if u, err := url.Parse(config.IPFSGateway); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("ipfsGateway must be an http(s) URL: %q", config.IPFSGateway)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxFetches <= 0 {
+		config.MaxFetches = DefaultMaxFetches
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultMaxBytes
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = DefaultCacheSize
+	}
+
+	dialer := &net.Dialer{Timeout: time.Duration(config.Timeout) * time.Second}
+	if !config.AllowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: time.Duration(config.Timeout) * time.Second,
+		MaxIdleConnsPerHost: 4,
+	}
+	return &Fetcher{
+		config: config,
+		client: &http.Client{Transport: transport, Timeout: time.Duration(config.Timeout) * time.Second},
+		cache:  make(map[string]cacheEntry),
+	}, nil
+}
+
+// refusePrivate is checked after the host is resolved, so names resolving
+// to internal addresses are refused as well.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context allowing the fetcher at most its MaxFetches
+// uncached fetches. Requests derive their context from it, so the cost of a
+// request is bounded however many tokens it checks.
+func (f *Fetcher) WithBudget(ctx context.Context) context.Context {
+	budget := new(atomic.Int64)
+	budget.Store(int64(f.config.MaxFetches))
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+func spend(ctx context.Context) error {
+	budget, ok := ctx.Value(budgetKey{}).(*atomic.Int64)
+	if ok && budget.Add(-1) < 0 {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// Fetch returns the metadata at a token URI. Fetched documents are cached
+// by the URL they are fetched from.
+func (f *Fetcher) Fetch(ctx context.Context, uri string) (Metadata, error) {
+	if strings.HasPrefix(uri, "data:") {
+		data, err := decodeDataURI(uri)
+		if err != nil {
+			return Metadata{}, err
+		}
+		return Parse(data)
+	}
+
+	target, err := f.resolve(uri)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if metadata, ok := f.cached(target); ok {
+		return metadata, nil
+	}
+	data, err := f.read(ctx, target)
+	if err != nil {
+		return Metadata{}, err
+	}
+	metadata, err := Parse(data)
+	if err != nil {
+		return Metadata{}, err
+	}
+	f.store(target, metadata)
+	return metadata, nil
+}
+
+func (f *Fetcher) cached(uri string) (Metadata, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.cache[uri]
+	if !ok || time.Now().After(entry.expires) {
+		return Metadata{}, false
+	}
+	return entry.metadata, true
+}
+
+func (f *Fetcher) store(uri string, metadata Metadata) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if len(f.cache) >= f.config.CacheSize {
+		for key, entry := range f.cache {
+			if now.After(entry.expires) {
+				delete(f.cache, key)
+			}
+		}
+	}
+	// Still full, drop an arbitrary entry.
+	for key := range f.cache {
+		if len(f.cache) < f.config.CacheSize {
+			break
+		}
+		delete(f.cache, key)
+	}
+	f.cache[uri] = cacheEntry{metadata: metadata, expires: now.Add(time.Duration(f.config.CacheTTL) * time.Second)}
+}
+
+// read fetches a document, spending one fetch of the request budget.
+func (f *Fetcher) read(ctx context.Context, target string) ([]byte, error) {
+	if err := spend(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", target, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.config.MaxBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrTooLarge, target, f.config.MaxBytes)
+	}
+	return data, nil
+}
+
+// resolve maps a token URI to the URL it is fetched from; ipfs:// URIs are
+// fetched through the gateway.
+func (f *Fetcher) resolve(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedURI, uri)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return uri, nil
+	case "ipfs":
+		path := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+		if path == "" {
+			break
+		}
+		return f.config.IPFSGateway + path, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedURI, uri)
+}
+
+// decodeDataURI decodes data:[<mediatype>][;base64],<data>.
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed data URI", ErrUnsupportedURI)
+	}
+	if strings.HasSuffix(header, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedURI, err)
+		}
+		return data, nil
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedURI, err)
+	}
+	return []byte(data), nil
+}
+
+// Parse decodes a metadata document. Trait values that are numbers or
+// booleans are formatted as strings, so they can be matched like any other.
+func Parse(data []byte) (Metadata, error) {
+	var document struct {
+		Name       string `json:"name"`
+		Attributes []struct {
+			TraitType string `json:"trait_type"`
+			Value     any    `json:"value"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return Metadata{}, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	metadata := Metadata{Name: document.Name}
+	for _, attribute := range document.Attributes {
+		metadata.Attributes = append(metadata.Attributes, Attribute{
+			TraitType: attribute.TraitType,
+			Value:     formatValue(attribute.Value),
+		})
+	}
+	return metadata, nil
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// Has reports whether the metadata has a trait with the given type and
+// value. Both are compared case-insensitively.
+func (m Metadata) Has(traitType, value string) bool {
+	for _, attribute := range m.Attributes {
+		if strings.EqualFold(strings.TrimSpace(attribute.TraitType), traitType) && strings.EqualFold(strings.TrimSpace(attribute.Value), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package metadata
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const legendary = `{"name": "Token #1", "attributes": [{"trait_type": "Rarity", "value": "Legendary"}, {"trait_type": "Level", "value": 5}, {"trait_type": "Shiny", "value": true}]}`
+
+func TestParse(t *testing.T) {
+	md, err := Parse([]byte(legendary))
+	assert.NoError(t, err)
+	assert.Equal(t, "Token #1", md.Name)
+	assert.True(t, md.Has("rarity", "legendary"), "Traits should match case-insensitively")
+	assert.True(t, md.Has("Level", "5"), "Numbers should match as strings")
+	assert.True(t, md.Has("Shiny", "true"), "Booleans should match as strings")
+	assert.False(t, md.Has("Rarity", "Common"))
+
+	_, err = Parse([]byte("<html>"))
+	assert.Error(t, err, "Invalid JSON should fail")
+}
+
+func TestFetch(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/token/1", "/ipfs/QmHash/1":
+			fmt.Fprint(w, legendary)
+		case "/large":
+			fmt.Fprint(w, strings.Repeat(" ", 2048)+legendary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fetcher, err := New(Config{IPFSGateway: srv.URL + "/ipfs", MaxFetches: 3, MaxBytes: 1024, AllowPrivate: true})
+	assert.NoError(t, err)
+	ctx := fetcher.WithBudget(context.Background())
+
+	tests := []struct {
+		uri string
+		err bool
+	}{
+		{srv.URL + "/token/1", false},
+		{"ipfs://QmHash/1", false},
+		{"ipfs://ipfs/QmHash/1", false},
+		{"data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(legendary)), false},
+		{"data:application/json," + strings.ReplaceAll(legendary, " ", "%20"), false},
+		{"ftp://example.com/1", true},
+		{"ipfs://", true},
+		{"data:application/json;base64,!!", true},
+	}
+	for _, test := range tests {
+		md, err := fetcher.Fetch(ctx, test.uri)
+		if test.err {
+			assert.Error(t, err, "Fetching %s should fail", test.uri)
+			continue
+		}
+		assert.NoError(t, err, "Fetching %s should succeed", test.uri)
+		assert.True(t, md.Has("Rarity", "Legendary"), "Metadata of %s should be parsed", test.uri)
+	}
+	assert.Equal(t, int32(2), requests.Load(), "Cached and data URIs should not be fetched")
+
+	_, err = fetcher.Fetch(ctx, srv.URL+"/large")
+	assert.True(t, errors.Is(err, ErrTooLarge), "Large documents should be refused, got %v", err)
+
+	_, err = fetcher.Fetch(ctx, srv.URL+"/missing")
+	assert.True(t, errors.Is(err, ErrBudgetExhausted), "Fetches beyond the budget should be refused, got %v", err)
+	assert.Equal(t, int32(3), requests.Load(), "Should not send requests beyond the budget")
+
+	_, err = fetcher.Fetch(fetcher.WithBudget(context.Background()), srv.URL+"/missing")
+	assert.Error(t, err, "Missing documents should fail")
+}
+
+func TestPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, legendary)
+	}))
+	defer srv.Close()
+
+	fetcher, err := New(Config{})
+	assert.NoError(t, err)
+	_, err = fetcher.Fetch(context.Background(), srv.URL+"/token/1")
+	assert.True(t, errors.Is(err, ErrPrivateAddress), "Loopback should be refused, got %v", err)
+
+	_, err = New(Config{IPFSGateway: "ipfs://gateway"})
+	assert.Error(t, err, "Gateway must be an http(s) URL")
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "vulcan"
+
+// Outcomes of a check.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFail    = "fail"
+	OutcomeError   = "error"
+)
+
+var (
+	CheckRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "check_requests_total",
+		Help:      "Checks evaluated, by network, standard and outcome.",
+	}, []string{"network", "standard", "outcome"})
+
+	CheckDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "check_duration_seconds",
+		Help:      "Latency of the requests evaluating a check, by network, standard and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"network", "standard", "outcome"})
+
+	RPCCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_call_duration_seconds",
+		Help:      "Latency of RPC calls, by network and endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"network", "endpoint"})
+
+	RPCCallErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_call_errors_total",
+		Help:      "Failed RPC calls, by network and endpoint.",
+	}, []string{"network", "endpoint"})
+
+	BatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_batch_size",
+		Help:      "Number of calls sent in one RPC request, by network.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"network"})
+
+	LinkedAccountsResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "linked_accounts_resolved_total",
+		Help:      "Linked accounts, such as FuturePasses, resolved for checked wallets, by network and source.",
+	}, []string{"network", "source"})
+
+	// FuturePassesResolved is the series before LinkedAccountsResolved,
+	// kept for existing dashboards. It counts only FuturePasses.
+	//
+	// Deprecated: use LinkedAccountsResolved with source futurepass.
+	FuturePassesResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "futurepasses_resolved_total",
+		Help:      "FuturePass addresses resolved for checked wallets, by network. Deprecated, use linked_accounts_resolved_total.",
+	}, []string{"network"})
+
+	EndpointUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_endpoint_up",
+		Help:      "Whether an RPC endpoint is connected and healthy (1) or not (0), by network and endpoint.",
+	}, []string{"network", "endpoint"})
+
+	EndpointBlockNumber = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_endpoint_block_number",
+		Help:      "Latest block number reported by an RPC endpoint, by network and endpoint.",
+	}, []string{"network", "endpoint"})
+)
+
+// Endpoint returns the label of an RPC URL. Only the scheme and host are
+// kept, as paths and query strings often hold API keys.
+func Endpoint(rpcURL string) string {
+	u, err := url.Parse(rpcURL)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// ObserveCheck records the outcome and request latency of a check.
+func ObserveCheck(network, standard, outcome string, duration time.Duration) {
+	CheckRequests.WithLabelValues(network, standard, outcome).Inc()
+	CheckDuration.WithLabelValues(network, standard, outcome).Observe(duration.Seconds())
+}
@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "https://eth-mainnet.g.alchemy.com", Endpoint("https://eth-mainnet.g.alchemy.com/v2/secretkey"))
+	assert.Equal(t, "wss://root.rootnet.live", Endpoint("wss://root.rootnet.live/ws?apikey=secret"))
+	assert.Equal(t, "invalid", Endpoint("not a url"))
+}
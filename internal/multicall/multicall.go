@@ -0,0 +1,272 @@
+package multicall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// maxCalls bounds the calls packed into a single aggregate3 call, so it stays
+// within the gas limit RPCs apply to eth_call.
+const maxCalls = 500
+
+var (
+	// Address is the canonical Multicall3 deployment, at the same address on
+	// most EVM chains.
+	Address = w3.A("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+	funcAggregate3 = w3.MustNewFunc("aggregate3((address target, bool allowFailure, bytes callData)[] calls)", "(bool success, bytes returnData)[] returnData")
+
+	ErrReverted = errors.New("execution reverted")
+
+	// errNoMulticall is returned when the Multicall3 address has no code, as
+	// an eth_call to such an address succeeds with empty output.
+	errNoMulticall = errors.New("multicall3 is not deployed")
+)
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Client packs the eth_calls of a batch into aggregate3 calls on Multicall3,
+// with allowFailure set for every call, and decodes the results back into
+// the original calls. Other methods, such as eth_getBalance, are sent along
+// unchanged in the same JSON-RPC batch. If Multicall3 turns out not to be
+// deployed, the client falls back to plain batching for good.
+type Client struct {
+	shared.RPCClient
+	network string
+	address common.Address
+
+	mu          sync.Mutex
+	unsupported bool
+}
+
+// New wraps the client of a network. address is the Multicall3 deployment,
+// usually Address.
+func New(client shared.RPCClient, network string, address common.Address) *Client {
+	return &Client{RPCClient: client, network: network, address: address}
+}
+
+func (c *Client) Call(calls ...w3types.Caller) error {
+	return c.CallCtx(context.Background(), calls...)
+}
+
+// packed is a call sent through aggregate3. elem is its original request,
+// whose Result receives the returned data.
+type packed struct {
+	index int
+	call  w3types.Caller
+	elem  rpc.BatchElem
+}
+
+func (c *Client) CallCtx(ctx context.Context, calls ...w3types.Caller) error {
+	if c.isUnsupported() || len(calls) < 2 {
+		return c.RPCClient.CallCtx(ctx, calls...)
+	}
+
+	// Group the eth_calls by block, keeping everything else as it is.
+	var blocks []string
+	groups := make(map[string][]packed)
+	var plain []int
+	for i, call := range calls {
+		elem, err := call.CreateRequest()
+		if err != nil {
+			return c.RPCClient.CallCtx(ctx, calls...)
+		}
+		block, ok := packable(elem)
+		if !ok {
+			plain = append(plain, i)
+			continue
+		}
+		if _, seen := groups[block]; !seen {
+			blocks = append(blocks, block)
+		}
+		groups[block] = append(groups[block], packed{index: i, call: call, elem: elem})
+	}
+
+	var aggregates []*aggregateCall
+	for _, block := range blocks {
+		group := groups[block]
+		for start := 0; start < len(group); start += maxCalls {
+			aggregates = append(aggregates, &aggregateCall{
+				address: c.address,
+				block:   block,
+				calls:   group[start:min(start+maxCalls, len(group))],
+			})
+		}
+	}
+
+	batch := make([]w3types.Caller, 0, len(aggregates)+len(plain))
+	for _, aggregate := range aggregates {
+		batch = append(batch, aggregate)
+	}
+	for _, i := range plain {
+		batch = append(batch, calls[i])
+	}
+
+	err := c.RPCClient.CallCtx(ctx, batch...)
+	var batchErrs w3.CallErrors
+	if err != nil && !errors.As(err, &batchErrs) {
+		return err
+	}
+
+	callErrs := make(w3.CallErrors, len(calls))
+	for j, i := range plain {
+		if batchErrs != nil {
+			callErrs[i] = batchErrs[len(aggregates)+j]
+		}
+	}
+
+	// Aggregates that failed as a whole are retried as plain calls.
+	var retry []packed
+	for _, aggregate := range aggregates {
+		if aggregate.err == nil {
+			aggregate.deliver(callErrs)
+			continue
+		}
+		if errors.Is(aggregate.err, errNoMulticall) {
+			c.markUnsupported(aggregate.err)
+		}
+		retry = append(retry, aggregate.calls...)
+	}
+	if len(retry) > 0 {
+		retryCalls := make([]w3types.Caller, len(retry))
+		for i, p := range retry {
+			retryCalls[i] = p.call
+		}
+		err := c.RPCClient.CallCtx(ctx, retryCalls...)
+		var retryErrs w3.CallErrors
+		if err != nil && !errors.As(err, &retryErrs) {
+			return err
+		}
+		for i, p := range retry {
+			if retryErrs != nil {
+				callErrs[p.index] = retryErrs[i]
+			}
+		}
+	}
+
+	for _, err := range callErrs {
+		if err != nil {
+			return callErrs
+		}
+	}
+	return nil
+}
+
+func (c *Client) isUnsupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unsupported
+}
+
+func (c *Client) markUnsupported(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.unsupported {
+		log.Printf("Multicall3 at %s on %s: %v, falling back to plain batching", c.address, c.network, err)
+		c.unsupported = true
+	}
+}
+
+// packable reports whether a request is a plain eth_call that Multicall3 can
+// make on its behalf, and returns its block argument. Results are decoded
+// either by eth.CallFunc into hexutil.Bytes or by eth.Call from raw JSON.
+func packable(elem rpc.BatchElem) (string, bool) {
+	if elem.Method != "eth_call" || len(elem.Args) != 2 {
+		return "", false
+	}
+	msg, ok := elem.Args[0].(*w3types.Message)
+	if !ok || msg.To == nil || msg.From != (common.Address{}) || msg.Value != nil && msg.Value.Sign() != 0 || msg.Gas != 0 || len(msg.AccessList) > 0 {
+		return "", false
+	}
+	switch elem.Result.(type) {
+	case *hexutil.Bytes, *json.RawMessage:
+	default:
+		return "", false
+	}
+	block, ok := elem.Args[1].(string)
+	return block, ok
+}
+
+// aggregateCall is a single aggregate3 call at block.
+type aggregateCall struct {
+	address common.Address
+	block   string
+	calls   []packed
+
+	output  hexutil.Bytes
+	results []result3
+	err     error
+}
+
+func (a *aggregateCall) CreateRequest() (rpc.BatchElem, error) {
+	calls := make([]call3, len(a.calls))
+	for i, p := range a.calls {
+		msg := p.elem.Args[0].(*w3types.Message)
+		calls[i] = call3{Target: *msg.To, AllowFailure: true, CallData: msg.Input}
+	}
+	input, err := funcAggregate3.EncodeArgs(calls)
+	if err != nil {
+		return rpc.BatchElem{}, err
+	}
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args:   []any{&w3types.Message{To: &a.address, Input: input}, a.block},
+		Result: &a.output,
+	}, nil
+}
+
+// HandleResponse keeps the error of the aggregate to itself, so the calls can
+// be retried without failing the batch.
+func (a *aggregateCall) HandleResponse(elem rpc.BatchElem) error {
+	switch {
+	case elem.Error != nil:
+		a.err = elem.Error
+	case len(a.output) == 0:
+		a.err = errNoMulticall
+	default:
+		if err := funcAggregate3.DecodeReturns(a.output, &a.results); err != nil {
+			a.err = err
+		} else if len(a.results) != len(a.calls) {
+			a.err = fmt.Errorf("aggregate3 returned %d results for %d calls", len(a.results), len(a.calls))
+		}
+	}
+	return nil
+}
+
+// deliver hands the result of every call to the call, as if it had been sent
+// on its own. Reverted calls get ErrReverted.
+func (a *aggregateCall) deliver(callErrs w3.CallErrors) {
+	for i, p := range a.calls {
+		elem := p.elem
+		switch result := elem.Result.(type) {
+		case *hexutil.Bytes:
+			*result = a.results[i].ReturnData
+		case *json.RawMessage:
+			*result, _ = json.Marshal(hexutil.Bytes(a.results[i].ReturnData))
+		}
+		if !a.results[i].Success {
+			elem.Error = fmt.Errorf("%w: 0x%x", ErrReverted, a.results[i].ReturnData)
+		}
+		callErrs[p.index] = p.call.HandleResponse(elem)
+	}
+}
@@ -0,0 +1,94 @@
+package native
+
+import (
+	"math/big"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/FN00EU/vulcan-one/internal/standard"
+	"github.com/FN00EU/vulcan-one/internal/units"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// DefaultDecimals are the decimals of eth_getBalance on networks without an
+// entry in nativeDecimals.
+const DefaultDecimals = 18
+
+func init() {
+	standard.Register(Checker{})
+}
+
+// Checker checks that a wallet holds at least amount of the native coin of a
+// network, such as ETH on eth and arb or XRP on trn. The amount is a decimal
+// number of coins, the contract is ignored.
+type Checker struct{}
+
+func (Checker) Names() []string {
+	return []string{"native"}
+}
+
+func (Checker) Contractless() {}
+
+func (Checker) NewCheck(network, contract, amount string) (standard.Check, error) {
+	condition, err := compare.Parse(amount)
+	if err != nil {
+		return nil, err
+	}
+	decimals := Decimals(network)
+	threshold, err := condition.Convert(func(amount string) (*big.Int, error) {
+		return units.ParseDecimal(amount, decimals)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &check{threshold: threshold, decimals: decimals}, nil
+}
+
+// Decimals returns the native decimals of a network from nativeDecimals in
+// configuration.json, or DefaultDecimals.
+func Decimals(network string) uint8 {
+	if shared.Config != nil {
+		if decimals, ok := shared.Config.NativeDecimals[network]; ok {
+			return decimals
+		}
+	}
+	return DefaultDecimals
+}
+
+type check struct {
+	threshold compare.Threshold
+	decimals  uint8
+	addresses []common.Address
+	balances  []big.Int
+}
+
+func (c *check) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	c.addresses = addresses
+	c.balances = make([]big.Int, len(addresses))
+
+	calls := make([]w3types.Caller, len(addresses))
+	for i, address := range addresses {
+		calls[i] = eth.Balance(address, blockNumber).Returns(&c.balances[i])
+	}
+	return calls
+}
+
+func (c *check) Decimals() uint8 {
+	return c.decimals
+}
+
+func (c *check) Results() ([]standard.Result, error) {
+	results := make([]standard.Result, len(c.balances))
+	for i := range c.balances {
+		balance := &c.balances[i]
+		results[i] = standard.Result{
+			Address:   c.addresses[i],
+			Balance:   balance,
+			Threshold: c.threshold,
+			Met:       c.threshold.Met(balance),
+		}
+	}
+	return results, nil
+}
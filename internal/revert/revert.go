@@ -0,0 +1,45 @@
+// Package revert tells calls that reverted in the EVM apart from calls that
+// failed in the node, such as timeouts, rate limits and malformed answers.
+// Only reverts say something about the contract, so only they may be taken
+// as "no such token" or "not deployed".
+package revert
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lmittmann/w3"
+)
+
+// Is reports whether err, the error of a single call, is a revert. Nodes
+// answer reverts with code 3 or an "execution reverted" message, and w3
+// reports revert reasons returned as output with w3.ErrEvmRevert.
+func Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, w3.ErrEvmRevert) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// Only reports whether err is a w3.CallErrors whose failed calls all
+// reverted.
+func Only(err error) bool {
+	var callErrs w3.CallErrors
+	if !errors.As(err, &callErrs) {
+		return false
+	}
+	for _, callErr := range callErrs {
+		if callErr != nil && !Is(callErr) {
+			return false
+		}
+	}
+	return true
+}
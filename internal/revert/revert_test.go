@@ -0,0 +1,46 @@
+package revert
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lmittmann/w3"
+	"github.com/stretchr/testify/assert"
+)
+
+// rpcError is a JSON-RPC error of a node.
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{rpcError{3, "execution reverted: ERC721: invalid token ID"}, true},
+		{rpcError{-32000, "execution reverted"}, true},
+		{fmt.Errorf("%w: Ownable: caller is not the owner", w3.ErrEvmRevert), true},
+		{errors.New("execution reverted: 0x"), true},
+		{rpcError{-32005, "rate limit exceeded"}, false},
+		{rpcError{-32000, "header not found"}, false},
+		{errors.New("abi: cannot marshal in to go type: length insufficient 1 require 32"), false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, Is(test.err), "%v", test.err)
+	}
+}
+
+func TestOnly(t *testing.T) {
+	reverted := rpcError{3, "execution reverted"}
+	assert.True(t, Only(w3.CallErrors{nil, reverted}))
+	assert.False(t, Only(w3.CallErrors{reverted, rpcError{-32005, "rate limit exceeded"}}))
+	assert.False(t, Only(errors.New("connection refused")), "Failed batches are not reverts")
+	assert.False(t, Only(nil))
+}
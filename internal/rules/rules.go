@@ -0,0 +1,247 @@
+package rules
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	MaxDepth  = 8
+	MaxLeaves = 32
+)
+
+var (
+	ErrEmptyRule     = errors.New("rule is empty")
+	ErrAmbiguousRule = errors.New("rule must have exactly one of and, or, not, asset or a check")
+	ErrRuleTooDeep   = fmt.Errorf("rule is nested deeper than %d levels", MaxDepth)
+	ErrTooManyChecks = fmt.Errorf("rule has more than %d checks", MaxLeaves)
+)
+
+// Rule is a boolean expression over balance checks. A rule is either a check
+// (network, standard, amount and contract, as in the
+// /api/:network/:standard/:amount/:contract route) or one of And, Or and Not
+// combining other rules. The contract may be empty for standards that do not
+// use one, such as native. An Asset is a check of the same token on several
+// networks.
+//
+//	{"and": [
+//	  {"network": "eth", "standard": "erc721", "amount": "1", "contract": "0x..."},
+//	  {"not": {"network": "arb", "standard": "erc20", "amount": "1000", "contract": "0x..."}}
+//	]}
+type Rule struct {
+	And []*Rule `json:"and,omitempty"`
+	Or  []*Rule `json:"or,omitempty"`
+	Not *Rule   `json:"not,omitempty"`
+
+	Network  string `json:"network,omitempty"`
+	Standard string `json:"standard,omitempty"`
+	Amount   string `json:"amount,omitempty"`
+	Contract string `json:"contract,omitempty"`
+
+	Asset *Asset `json:"asset,omitempty"`
+}
+
+// Asset is the same logical token deployed on several networks, with one
+// contract per network. Its balances on all networks are normalized by the
+// decimals of each deployment, added up and compared with Amount.
+//
+//	{"asset": {"standard": "erc20", "amount": "1000",
+//	  "contracts": {"eth": "0x...", "arb": "0x...", "trn": "0x..."}}}
+type Asset struct {
+	Standard  string            `json:"standard"`
+	Amount    string            `json:"amount"`
+	Contracts map[string]string `json:"contracts"`
+}
+
+// Deployments returns a check of every network of the asset, sorted by
+// network.
+func (a *Asset) Deployments() []*Rule {
+	networks := make([]string, 0, len(a.Contracts))
+	for network := range a.Contracts {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	deployments := make([]*Rule, len(networks))
+	for i, network := range networks {
+		deployments[i] = NewCheck(network, a.Standard, a.Amount, a.Contracts[network])
+	}
+	return deployments
+}
+
+// NewCheck returns a rule consisting of a single check.
+func NewCheck(network, standard, amount, contract string) *Rule {
+	return &Rule{Network: network, Standard: standard, Amount: amount, Contract: contract}
+}
+
+// IsCheck reports whether the rule is a leaf balance check.
+func (r *Rule) IsCheck() bool {
+	return r.Network != "" || r.Standard != "" || r.Amount != "" || r.Contract != ""
+}
+
+// IsLeaf reports whether the rule is a check or an asset.
+func (r *Rule) IsLeaf() bool {
+	return r.IsCheck() || r.Asset != nil
+}
+
+// Validate checks the structure of the rule. The checks themselves are
+// validated by the API against the configured networks and standards.
+func (r *Rule) Validate() error {
+	leaves := 0
+	return r.validate(1, &leaves)
+}
+
+func (r *Rule) validate(depth int, leaves *int) error {
+	if r == nil {
+		return ErrEmptyRule
+	}
+	if depth > MaxDepth {
+		return ErrRuleTooDeep
+	}
+
+	kinds := 0
+	if r.And != nil {
+		kinds++
+	}
+	if r.Or != nil {
+		kinds++
+	}
+	if r.Not != nil {
+		kinds++
+	}
+	if r.IsCheck() {
+		kinds++
+	}
+	if r.Asset != nil {
+		kinds++
+	}
+	if kinds == 0 {
+		return ErrEmptyRule
+	}
+	if kinds > 1 {
+		return ErrAmbiguousRule
+	}
+
+	if r.IsCheck() {
+		*leaves++
+		if *leaves > MaxLeaves {
+			return ErrTooManyChecks
+		}
+		if r.Network == "" || r.Standard == "" || r.Amount == "" {
+			return errors.New("check must have network, standard and amount")
+		}
+		return nil
+	}
+
+	if r.Asset != nil {
+		*leaves += len(r.Asset.Contracts)
+		if *leaves > MaxLeaves {
+			return ErrTooManyChecks
+		}
+		if r.Asset.Standard == "" || r.Asset.Amount == "" || len(r.Asset.Contracts) == 0 {
+			return errors.New("asset must have standard, amount and contracts")
+		}
+		for network, contract := range r.Asset.Contracts {
+			if network == "" || contract == "" {
+				return errors.New("asset contracts must map networks to contracts")
+			}
+		}
+		return nil
+	}
+
+	if r.Not != nil {
+		return r.Not.validate(depth+1, leaves)
+	}
+
+	children := r.And
+	if r.Or != nil {
+		children = r.Or
+	}
+	if len(children) == 0 {
+		return ErrEmptyRule
+	}
+	for _, child := range children {
+		if err := child.validate(depth+1, leaves); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks returns every leaf check and asset of the rule in depth-first order.
+func (r *Rule) Checks() []*Rule {
+	if r == nil {
+		return nil
+	}
+	if r.IsLeaf() {
+		return []*Rule{r}
+	}
+
+	var checks []*Rule
+	for _, child := range r.And {
+		checks = append(checks, child.Checks()...)
+	}
+	for _, child := range r.Or {
+		checks = append(checks, child.Checks()...)
+	}
+	return append(checks, r.Not.Checks()...)
+}
+
+// Evaluate computes the rule using result to obtain the outcome of each check.
+func (r *Rule) Evaluate(result func(check *Rule) bool) bool {
+	switch {
+	case r.IsLeaf():
+		return result(r)
+	case r.Not != nil:
+		return !r.Not.Evaluate(result)
+	case r.And != nil:
+		for _, child := range r.And {
+			if !child.Evaluate(result) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, child := range r.Or {
+			if child.Evaluate(result) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Decode parses a rule from a path segment. The segment is either the JSON
+// rule itself or its base64url encoding.
+func Decode(segment string) (*Rule, error) {
+	data := []byte(segment)
+	if !strings.HasPrefix(strings.TrimSpace(segment), "{") {
+		var err error
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule encoding: %v", err)
+		}
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("invalid rule: %v", err)
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Encode returns the base64url encoding of the rule for use in a path segment.
+func Encode(rule *Rule) (string, error) {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
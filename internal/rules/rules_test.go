@@ -0,0 +1,97 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+const genesisAndToken = `{"and": [
+	{"network": "eth", "standard": "erc721", "amount": "1", "contract": "0x01"},
+	{"or": [
+		{"network": "eth", "standard": "erc20", "amount": "1000", "contract": "0x02"},
+		{"not": {"network": "arb", "standard": "erc20", "amount": "1", "contract": "0x03"}}
+	]}
+]}`
+
+func TestDecode(t *testing.T) {
+	// Test case 1: Plain JSON
+	rule, err := rules.Decode(genesisAndToken)
+	assert.NoError(t, err, "Should not return an error")
+	assert.Len(t, rule.Checks(), 3, "Should have 3 checks")
+
+	// Test case 2: base64url round trip
+	encoded, err := rules.Encode(rule)
+	assert.NoError(t, err, "Should not return an error")
+	decoded, err := rules.Decode(encoded)
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, rule, decoded, "Should be equal")
+
+	// Test case 3: Invalid encoding
+	_, err = rules.Decode("not*base64")
+	assert.Error(t, err, "Should return an error")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectError bool
+	}{
+		{`{"network": "eth", "standard": "erc20", "amount": "1", "contract": "0x01"}`, false},
+		{`{}`, true},
+		{`{"and": []}`, true},
+		{`{"network": "eth", "standard": "erc20"}`, true},
+		{`{"not": {"network": "eth", "standard": "erc20", "amount": "1", "contract": "0x01"}, "and": [{"network": "eth", "standard": "erc20", "amount": "1", "contract": "0x01"}]}`, true},
+		{`{"not":{"not":{"not":{"not":{"not":{"not":{"not":{"not":{"network": "eth", "standard": "erc20", "amount": "1", "contract": "0x01"}}}}}}}}}`, true},
+		{`{"asset": {"standard": "erc20", "amount": "1", "contracts": {"eth": "0x01", "arb": "0x02"}}}`, false},
+		{`{"asset": {"standard": "erc20", "amount": "1", "contracts": {}}}`, true},
+		{`{"asset": {"standard": "erc20", "contracts": {"eth": "0x01"}}}`, true},
+		{`{"asset": {"standard": "erc20", "amount": "1", "contracts": {"eth": ""}}}`, true},
+		{`{"network": "eth", "standard": "erc20", "amount": "1", "asset": {"standard": "erc20", "amount": "1", "contracts": {"eth": "0x01"}}}`, true},
+	}
+
+	for _, test := range tests {
+		_, err := rules.Decode(test.input)
+		if (err != nil) != test.expectError {
+			t.Errorf("Test case %q failed: expected error %v, but got %v", test.input, test.expectError, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rule, err := rules.Decode(genesisAndToken)
+	assert.NoError(t, err, "Should not return an error")
+	checks := rule.Checks()
+
+	evaluate := func(results ...bool) bool {
+		return rule.Evaluate(func(check *rules.Rule) bool {
+			for i, c := range checks {
+				if c == check {
+					return results[i]
+				}
+			}
+			t.Fatalf("unknown check %v", check)
+			return false
+		})
+	}
+
+	assert.True(t, evaluate(true, true, true), "NFT and tokens should pass")
+	assert.True(t, evaluate(true, false, false), "NFT and not holding on arb should pass")
+	assert.False(t, evaluate(true, false, true), "NFT without tokens should fail")
+	assert.False(t, evaluate(false, true, false), "Tokens without NFT should fail")
+}
+
+func TestAssetDeployments(t *testing.T) {
+	rule, err := rules.Decode(`{"asset": {"standard": "erc20", "amount": "1.5", "contracts": {"eth": "0x01", "arb": "0x02"}}}`)
+	assert.NoError(t, err, "Should not return an error")
+	assert.True(t, rule.IsLeaf(), "Asset should be a leaf")
+	assert.Equal(t, []*rules.Rule{rule}, rule.Checks(), "Asset should be a single check")
+
+	deployments := rule.Asset.Deployments()
+	assert.Len(t, deployments, 2, "Should have one deployment per network")
+	assert.Equal(t, "arb", deployments[0].Network, "Deployments should be sorted by network")
+	assert.Equal(t, "0x02", deployments[0].Contract)
+	assert.Equal(t, "erc20", deployments[1].Standard)
+	assert.Equal(t, "1.5", deployments[1].Amount)
+}
@@ -0,0 +1,214 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrRuleReadOnly = errors.New("rule is defined in the configuration file")
+	ErrInvalidName  = errors.New("rule name must be 1-64 letters, digits, '-' or '_'")
+	validRuleName   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	storeFileMode   = os.FileMode(0o600)
+	storeDirMode    = os.FileMode(0o750)
+)
+
+const (
+	DefaultStorePath  = "./configs/rules.json"
+	errStoreUnmarshal = "error unmarshalling rules file %s: %v"
+)
+
+// Store holds named rules. Rules from the configuration file are read-only,
+// rules managed through the admin API are persisted to a JSON file and
+// override configured rules of the same name. The file is reloaded whenever
+// it changes on disk, so edits take effect without a restart.
+type Store struct {
+	mu         sync.RWMutex
+	path       string
+	configured map[string]*Rule
+	persisted  map[string]*Rule
+	modTime    time.Time
+}
+
+// NewStore returns a Store with the configured rules and the rules persisted
+// in path. A missing file is created on the first write.
+func NewStore(path string, configured map[string]*Rule) (*Store, error) {
+	for name, rule := range configured {
+		if !validRuleName.MatchString(name) {
+			return nil, fmt.Errorf("%s: %w", name, ErrInvalidName)
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+
+	s := &Store{
+		path:       filepath.Clean(path),
+		configured: configured,
+		persisted:  make(map[string]*Rule),
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload reads the rules file if it changed since the last read.
+func (s *Store) reload() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	persisted := make(map[string]*Rule)
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return fmt.Errorf(errStoreUnmarshal, s.path, err)
+	}
+	for name, rule := range persisted {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.persisted = persisted
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns the rule with the given name.
+func (s *Store) Get(name string) (*Rule, error) {
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if rule, ok := s.persisted[name]; ok {
+		return rule, nil
+	}
+	if rule, ok := s.configured[name]; ok {
+		return rule, nil
+	}
+	return nil, ErrRuleNotFound
+}
+
+// Names returns the names of all rules in alphabetical order.
+func (s *Store) Names() ([]string, error) {
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	unique := make(map[string]struct{})
+	for name := range s.configured {
+		unique[name] = struct{}{}
+	}
+	for name := range s.persisted {
+		unique[name] = struct{}{}
+	}
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Put validates and persists a rule under the given name.
+func (s *Store) Put(name string, rule *Rule) error {
+	if !validRuleName.MatchString(name) {
+		return ErrInvalidName
+	}
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := s.reload(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	persisted := make(map[string]*Rule, len(s.persisted)+1)
+	for k, v := range s.persisted {
+		persisted[k] = v
+	}
+	persisted[name] = rule
+	return s.save(persisted)
+}
+
+// Delete removes a persisted rule. Rules from the configuration file cannot
+// be deleted.
+func (s *Store) Delete(name string) error {
+	if err := s.reload(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.persisted[name]; !ok {
+		if _, ok := s.configured[name]; ok {
+			return ErrRuleReadOnly
+		}
+		return ErrRuleNotFound
+	}
+	persisted := make(map[string]*Rule, len(s.persisted))
+	for k, v := range s.persisted {
+		if k != name {
+			persisted[k] = v
+		}
+	}
+	return s.save(persisted)
+}
+
+// save writes the rules to a temporary file and renames it over the rules
+// file. Must be called with s.mu held.
+func (s *Store) save(persisted map[string]*Rule) error {
+	data, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), storeDirMode); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, storeFileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	s.persisted = persisted
+	s.modTime = info.ModTime()
+	return nil
+}
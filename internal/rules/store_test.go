@@ -0,0 +1,63 @@
+package rules_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	configured := map[string]*rules.Rule{
+		"genesis": rules.NewCheck("eth", "erc721", "1", "0x01"),
+	}
+
+	store, err := rules.NewStore(path, configured)
+	assert.NoError(t, err, "Should not return an error")
+
+	// Test case 1: Configured rule
+	rule, err := store.Get("genesis")
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, "erc721", rule.Standard, "Standard should match")
+
+	// Test case 2: Unknown rule
+	_, err = store.Get("unknown")
+	assert.ErrorIs(t, err, rules.ErrRuleNotFound, "Should return ErrRuleNotFound")
+
+	// Test case 3: Put persists and overrides the configured rule
+	err = store.Put("genesis", rules.NewCheck("eth", "erc721", "2", "0x01"))
+	assert.NoError(t, err, "Should not return an error")
+	err = store.Put("whale", rules.NewCheck("eth", "erc20", "1000", "0x02"))
+	assert.NoError(t, err, "Should not return an error")
+
+	reopened, err := rules.NewStore(path, configured)
+	assert.NoError(t, err, "Should not return an error")
+	rule, err = reopened.Get("genesis")
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, "2", rule.Amount, "Persisted rule should override configured rule")
+	names, err := reopened.Names()
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, []string{"genesis", "whale"}, names, "Names should match")
+
+	// Test case 4: Invalid name and rule
+	assert.ErrorIs(t, store.Put("bad name", rules.NewCheck("eth", "erc20", "1", "0x02")), rules.ErrInvalidName, "Should reject invalid name")
+	assert.Error(t, store.Put("empty", &rules.Rule{}), "Should reject empty rule")
+
+	// Test case 5: Delete
+	assert.NoError(t, store.Delete("genesis"), "Should delete persisted rule")
+	assert.ErrorIs(t, store.Delete("genesis"), rules.ErrRuleReadOnly, "Configured rule should be read-only")
+	assert.ErrorIs(t, store.Delete("unknown"), rules.ErrRuleNotFound, "Should return ErrRuleNotFound")
+
+	// Test case 6: Edits to the file are picked up without a restart
+	content := `{"whale": {"network": "eth", "standard": "erc20", "amount": "5000", "contract": "0x02"}}`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	rule, err = reopened.Get("whale")
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, "5000", rule.Amount, "Edited rule should be reloaded")
+}
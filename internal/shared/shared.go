@@ -1,29 +1,90 @@
 package shared
 
 import (
+	"context"
 	"sync"
 
+	"github.com/FN00EU/vulcan-one/internal/allowlist"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/metadata"
+	"github.com/FN00EU/vulcan-one/internal/rules"
+	"github.com/FN00EU/vulcan-one/internal/substrate"
 	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/w3types"
 )
 
 var (
 	Config            *Configuration
 	ClientMutex       sync.Mutex
-	Clients           = make(map[string]*w3.Client)
+	Clients           = make(map[string]RPCClient)
+	Rules             *rules.Store
+	AllowList         *allowlist.List
+	Metadata          *metadata.Fetcher
+	LinkedAccounts    = make(map[string][]linked.LinkedAccountResolver)
+	SubstrateClients  = make(map[string]*substrate.Client)
 	fpContractAddress = w3.A("0x000000000000000000000000000000000000FFFF")
 )
 
 type Configuration struct {
-	EVMnetworks    map[string][]string `json:"evmNetworks"`
-	Port           string              `json:"port"`
-	ValidStandards []string            `json:"validStandards"`
+	EVMnetworks       map[string][]string        `json:"evmNetworks"`
+	Port              string                     `json:"port"`
+	ValidStandards    []string                   `json:"validStandards"`
+	Rules             map[string]*rules.Rule     `json:"rules"`
+	RulesFile         string                     `json:"rulesFile"`
+	AdminToken        string                     `json:"adminToken"`
+	SIWE              SIWEConfig                 `json:"siwe"`
+	AllowList         allowlist.Config           `json:"allowList"`
+	NativeDecimals    map[string]uint8           `json:"nativeDecimals"`
+	Metadata          metadata.Config            `json:"metadata"`
+	Limits            limits.Config              `json:"limits"`
+	Multicall3        map[string]string          `json:"multicall3"`
+	LinkedAccounts    map[string][]linked.Config `json:"linkedAccounts"`
+	SubstrateNetworks map[string]string          `json:"substrateNetworks"`
+}
+
+// SIWEConfig configures Sign-In With Ethereum wallet ownership proofs.
+// SessionTTL is in seconds. Required defaults to true once a Domain is set,
+// see SessionRequired.
+type SIWEConfig struct {
+	Domain        string `json:"domain"`
+	Required      *bool  `json:"required"`
+	SessionSecret string `json:"sessionSecret"`
+	SessionTTL    int    `json:"sessionTTL"`
+}
+
+// SessionRequired reports whether gating calls need a session token: as
+// configured, or else whenever Sign-In With Ethereum is enabled by a Domain.
+func (c SIWEConfig) SessionRequired() bool {
+	if c.Required != nil {
+		return *c.Required
+	}
+	return c.Domain != ""
+}
+
+// Limits returns the request limits of the configuration, or the defaults.
+func Limits() limits.Config {
+	if Config == nil {
+		return limits.Config{}.WithDefaults()
+	}
+	return Config.Limits.WithDefaults()
+}
+
+// RPCClient is the subset of *w3.Client used by the API. It is implemented by
+// w3client.Pool, which fails over between the RPC URLs of a network.
+type RPCClient interface {
+	Call(calls ...w3types.Caller) error
+	CallCtx(ctx context.Context, calls ...w3types.Caller) error
+	Close() error
 }
 
 const (
 	errRpcUnavailable    = "RPC url %s cannot be reached"
-	errIncorrectStandard = "Standard %s is not supported"
+	ErrIncorrectStandard = "Standard %s is not supported"
 	ErrInvalidRequest    = "Invalid JSON request"
 	ErrUnmarshalJSON     = "error unmarshalling configuration: %v"
+	ErrNoHealthyEndpoint = "no healthy RPC endpoint for %s"
 	LogConnected         = "Connected to %s. Current block number: %d\n"
+	LogFailover          = "RPC call to %s failed, trying next endpoint: %v\n"
 	addressNull          = "0x0000000000000000000000000000000000000000"
 )
@@ -0,0 +1,157 @@
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	headerSuffix = " wants you to sign in with your Ethereum account:"
+	version      = "1"
+)
+
+var (
+	ErrInvalidMessage = errors.New("invalid EIP-4361 message")
+	ErrExpired        = errors.New("message has expired")
+	ErrNotYetValid    = errors.New("message is not valid yet")
+	ErrDomainMismatch = errors.New("message domain does not match")
+)
+
+// Message is a parsed EIP-4361 Sign-In With Ethereum message.
+type Message struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseMessage parses the text of an EIP-4361 message.
+func ParseMessage(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 6 {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidMessage)
+	}
+
+	var msg Message
+	if !strings.HasSuffix(lines[0], headerSuffix) {
+		return nil, fmt.Errorf("%w: line 1 must end with %q", ErrInvalidMessage, headerSuffix)
+	}
+	msg.Domain = strings.TrimSuffix(lines[0], headerSuffix)
+	if msg.Domain == "" {
+		return nil, fmt.Errorf("%w: missing domain", ErrInvalidMessage)
+	}
+
+	if !common.IsHexAddress(lines[1]) || !strings.HasPrefix(lines[1], "0x") {
+		return nil, fmt.Errorf("%w: line 2 must be an address", ErrInvalidMessage)
+	}
+	msg.Address = common.HexToAddress(lines[1])
+	if lines[1] != msg.Address.Hex() {
+		return nil, fmt.Errorf("%w: address must be EIP-55 checksummed", ErrInvalidMessage)
+	}
+
+	// An empty line follows the address. The optional statement is followed
+	// by another empty line.
+	i := 2
+	if lines[i] != "" {
+		return nil, fmt.Errorf("%w: line 3 must be empty", ErrInvalidMessage)
+	}
+	i++
+	if !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		i++
+		if i >= len(lines) || lines[i] != "" {
+			return nil, fmt.Errorf("%w: statement must be followed by an empty line", ErrInvalidMessage)
+		}
+		i++
+	}
+
+	fields := make(map[string]string)
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "Resources:" {
+			for i++; i < len(lines); i++ {
+				if !strings.HasPrefix(lines[i], "- ") {
+					return nil, fmt.Errorf("%w: invalid resource on line %d", ErrInvalidMessage, i+1)
+				}
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+			break
+		}
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			return nil, fmt.Errorf("%w: invalid field on line %d", ErrInvalidMessage, i+1)
+		}
+		if _, exists := fields[key]; exists {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidMessage, key)
+		}
+		fields[key] = value
+	}
+
+	var err error
+	msg.URI = fields["URI"]
+	msg.Version = fields["Version"]
+	msg.Nonce = fields["Nonce"]
+	msg.RequestID = fields["Request ID"]
+	if msg.URI == "" || msg.Nonce == "" {
+		return nil, fmt.Errorf("%w: URI and Nonce are required", ErrInvalidMessage)
+	}
+	if msg.Version != version {
+		return nil, fmt.Errorf("%w: version must be %s", ErrInvalidMessage, version)
+	}
+	if msg.ChainID, err = strconv.ParseUint(fields["Chain ID"], 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid Chain ID", ErrInvalidMessage)
+	}
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, fmt.Errorf("%w: invalid Issued At", ErrInvalidMessage)
+	}
+	if msg.ExpirationTime, err = optionalTime(fields, "Expiration Time"); err != nil {
+		return nil, err
+	}
+	if msg.NotBefore, err = optionalTime(fields, "Not Before"); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func optionalTime(fields map[string]string, key string) (*time.Time, error) {
+	value, ok := fields[key]
+	if !ok {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s", ErrInvalidMessage, key)
+	}
+	return &t, nil
+}
+
+// Validate checks the domain and the validity period of the message at now.
+func (msg *Message) Validate(domain string, now time.Time) error {
+	if !strings.EqualFold(msg.Domain, domain) {
+		return ErrDomainMismatch
+	}
+	if msg.ExpirationTime != nil && !now.Before(*msg.ExpirationTime) {
+		return ErrExpired
+	}
+	if msg.NotBefore != nil && now.Before(*msg.NotBefore) {
+		return ErrNotYetValid
+	}
+	if msg.IssuedAt.After(now.Add(maxClockSkew)) {
+		return ErrNotYetValid
+	}
+	return nil
+}
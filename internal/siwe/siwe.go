@@ -0,0 +1,192 @@
+package siwe
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+)
+
+const (
+	NonceTTL     = 10 * time.Minute
+	SessionTTL   = 24 * time.Hour
+	maxClockSkew = time.Minute
+	maxNonces    = 100000
+)
+
+var (
+	ErrInvalidSignature = errors.New("signature does not match the message address")
+	ErrUnknownNonce     = errors.New("nonce is unknown, expired or already used")
+	ErrInvalidToken     = errors.New("invalid session token")
+	ErrTokenExpired     = errors.New("session token has expired")
+	ErrTooManyNonces    = errors.New("too many pending nonces")
+
+	// magicValue is returned by EIP-1271 isValidSignature for a valid signature.
+	magicValue            = [4]byte{0x16, 0x26, 0xba, 0x7e}
+	funcIsValidSignature  = w3.MustNewFunc("isValidSignature(bytes32,bytes)", "bytes4")
+	tokenEncoding         = base64.RawURLEncoding
+	errSignatureMalformed = errors.New("signature must be 65 bytes")
+)
+
+// Nonces issues single-use nonces for SIWE messages.
+type Nonces struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewNonces() *Nonces {
+	return &Nonces{nonces: make(map[string]time.Time)}
+}
+
+// Issue returns a new random nonce valid for NonceTTL.
+func (n *Nonces) Issue(now time.Time) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k, expiry := range n.nonces {
+		if now.After(expiry) {
+			delete(n.nonces, k)
+		}
+	}
+	if len(n.nonces) >= maxNonces {
+		return "", ErrTooManyNonces
+	}
+	n.nonces[nonce] = now.Add(NonceTTL)
+	return nonce, nil
+}
+
+// Consume invalidates a nonce and reports whether it was valid.
+func (n *Nonces) Consume(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	expiry, ok := n.nonces[nonce]
+	delete(n.nonces, nonce)
+	return ok && !now.After(expiry)
+}
+
+// VerifySignature checks an EIP-191 personal_sign signature of the message
+// text. If the signer is not the message address and client is not nil, the
+// address is asked to validate the signature as an EIP-1271 smart-contract
+// wallet.
+func VerifySignature(ctx context.Context, client shared.RPCClient, msg *Message, text string, signature string) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return errSignatureMalformed
+	}
+	hash := accounts.TextHash([]byte(text))
+
+	if len(sig) == crypto.SignatureLength {
+		recoverable := append([]byte(nil), sig...)
+		if recoverable[crypto.RecoveryIDOffset] >= 27 {
+			recoverable[crypto.RecoveryIDOffset] -= 27
+		}
+		if pub, err := crypto.SigToPub(hash, recoverable); err == nil && crypto.PubkeyToAddress(*pub) == msg.Address {
+			return nil
+		}
+	}
+
+	if client == nil {
+		return ErrInvalidSignature
+	}
+
+	var result [4]byte
+	var digest [32]byte
+	copy(digest[:], hash)
+	call := eth.CallFunc(msg.Address, funcIsValidSignature, digest, sig).Returns(&result)
+	if err := client.CallCtx(ctx, call); err != nil || !bytes.Equal(result[:], magicValue[:]) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Session lists the wallets proven by SIWE signatures.
+type Session struct {
+	Addresses []common.Address `json:"addresses"`
+	Expires   int64            `json:"exp"`
+}
+
+// Add appends an address unless the session already holds it.
+func (s *Session) Add(address common.Address) {
+	for _, a := range s.Addresses {
+		if a == address {
+			return
+		}
+	}
+	s.Addresses = append(s.Addresses, address)
+}
+
+// Contains reports whether the session proves the address.
+func (s *Session) Contains(address string) bool {
+	if !common.IsHexAddress(address) {
+		return false
+	}
+	for _, a := range s.Addresses {
+		if a == common.HexToAddress(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignToken encodes the session as payload.mac, authenticated with
+// HMAC-SHA256 under secret.
+func SignToken(secret []byte, session *Session) (string, error) {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	encoded := tokenEncoding.EncodeToString(payload)
+	return encoded + "." + tokenEncoding.EncodeToString(mac(secret, encoded)), nil
+}
+
+// ParseToken verifies a token created by SignToken and returns its session.
+func ParseToken(secret []byte, token string, now time.Time) (*Session, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidToken
+	}
+	given, err := tokenEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, mac(secret, encoded)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := tokenEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= session.Expires {
+		return nil, ErrTokenExpired
+	}
+	return &session, nil
+}
+
+func mac(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,129 @@
+package siwe_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/siwe"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// signMessage returns an EIP-4361 message for the key and its EIP-191
+// signature.
+func signMessage(t *testing.T, domain, nonce string, issuedAt time.Time) (string, string, common.Address) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	text := fmt.Sprintf(`%s wants you to sign in with your Ethereum account:
+%s
+
+Prove wallet ownership for Discord roles.
+
+URI: https://%s/login
+Version: 1
+Chain ID: 1
+Nonce: %s
+Issued At: %s
+Expiration Time: %s
+Resources:
+- https://%s/terms`, domain, address.Hex(), domain, nonce,
+		issuedAt.Format(time.RFC3339), issuedAt.Add(time.Hour).Format(time.RFC3339), domain)
+
+	sig, err := crypto.Sign(accounts.TextHash([]byte(text)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return text, hexutil.Encode(sig), address
+}
+
+func TestParseMessage(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	text, _, address := signMessage(t, "vulcan.example.com", "abc123", now)
+
+	// Test case 1: Valid message
+	msg, err := siwe.ParseMessage(text)
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, "vulcan.example.com", msg.Domain, "Domain should match")
+	assert.Equal(t, address, msg.Address, "Address should match")
+	assert.Equal(t, "Prove wallet ownership for Discord roles.", msg.Statement, "Statement should match")
+	assert.Equal(t, uint64(1), msg.ChainID, "Chain ID should match")
+	assert.Equal(t, "abc123", msg.Nonce, "Nonce should match")
+	assert.Equal(t, []string{"https://vulcan.example.com/terms"}, msg.Resources, "Resources should match")
+	assert.NoError(t, msg.Validate("vulcan.example.com", now), "Message should be valid")
+
+	// Test case 2: Validity period and domain
+	assert.ErrorIs(t, msg.Validate("evil.example.com", now), siwe.ErrDomainMismatch, "Domain should be checked")
+	assert.ErrorIs(t, msg.Validate("vulcan.example.com", now.Add(2*time.Hour)), siwe.ErrExpired, "Expiration should be checked")
+	assert.ErrorIs(t, msg.Validate("vulcan.example.com", now.Add(-time.Hour)), siwe.ErrNotYetValid, "Issued At should be checked")
+
+	// Test case 3: Invalid messages
+	for _, invalid := range []string{
+		"",
+		"vulcan.example.com wants you to sign in with your Ethereum account:\n0x123\n\nURI: a\nVersion: 1\nChain ID: 1\nNonce: n\nIssued At: 2024-01-01T00:00:00Z",
+		"vulcan.example.com wants you to sign in with your Ethereum account:\n" + address.Hex() + "\n\nURI: a\nVersion: 2\nChain ID: 1\nNonce: n\nIssued At: 2024-01-01T00:00:00Z",
+		"vulcan.example.com wants you to sign in with your Ethereum account:\n" + address.Hex() + "\n\nURI: a\nVersion: 1\nChain ID: one\nNonce: n\nIssued At: 2024-01-01T00:00:00Z",
+	} {
+		_, err := siwe.ParseMessage(invalid)
+		assert.ErrorIs(t, err, siwe.ErrInvalidMessage, "Should reject %q", invalid)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	text, signature, _ := signMessage(t, "vulcan.example.com", "abc123", time.Now())
+	msg, err := siwe.ParseMessage(text)
+	assert.NoError(t, err, "Should not return an error")
+
+	assert.NoError(t, siwe.VerifySignature(context.Background(), nil, msg, text, signature), "Signature should be valid")
+
+	otherText, otherSignature, _ := signMessage(t, "vulcan.example.com", "abc123", time.Now())
+	assert.ErrorIs(t, siwe.VerifySignature(context.Background(), nil, msg, text, otherSignature), siwe.ErrInvalidSignature, "Signature of another key should be rejected")
+	assert.Error(t, siwe.VerifySignature(context.Background(), nil, msg, otherText, signature), "Signature of another message should be rejected")
+	assert.Error(t, siwe.VerifySignature(context.Background(), nil, msg, text, "0x1234"), "Malformed signature should be rejected")
+}
+
+func TestNonces(t *testing.T) {
+	nonces := siwe.NewNonces()
+	now := time.Now()
+
+	nonce, err := nonces.Issue(now)
+	assert.NoError(t, err, "Should not return an error")
+	assert.True(t, nonces.Consume(nonce, now), "Nonce should be valid once")
+	assert.False(t, nonces.Consume(nonce, now), "Nonce should not be reusable")
+
+	expired, _ := nonces.Issue(now)
+	assert.False(t, nonces.Consume(expired, now.Add(siwe.NonceTTL+time.Second)), "Expired nonce should be rejected")
+}
+
+func TestToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	session := &siwe.Session{Expires: now.Add(time.Hour).Unix()}
+	session.Add(common.HexToAddress("0x0A"))
+	session.Add(common.HexToAddress("0x0A"))
+	session.Add(common.HexToAddress("0x0B"))
+	assert.Len(t, session.Addresses, 2, "Addresses should be unique")
+
+	token, err := siwe.SignToken(secret, session)
+	assert.NoError(t, err, "Should not return an error")
+
+	parsed, err := siwe.ParseToken(secret, token, now)
+	assert.NoError(t, err, "Should not return an error")
+	assert.True(t, parsed.Contains("0x000000000000000000000000000000000000000a"), "Session should contain the wallet")
+	assert.False(t, parsed.Contains("0x000000000000000000000000000000000000000c"), "Session should not contain other wallets")
+
+	_, err = siwe.ParseToken([]byte("other"), token, now)
+	assert.ErrorIs(t, err, siwe.ErrInvalidToken, "Token signed with another secret should be rejected")
+	_, err = siwe.ParseToken(secret, token+"x", now)
+	assert.ErrorIs(t, err, siwe.ErrInvalidToken, "Tampered token should be rejected")
+	_, err = siwe.ParseToken(secret, token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, siwe.ErrTokenExpired, "Expired token should be rejected")
+}
@@ -0,0 +1,200 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// searchFanout is the number of headers requested per batch while searching
+// for the block of a timestamp.
+const searchFanout = 8
+
+var (
+	ErrInvalidSpec     = errors.New("block must be a number or a tag (latest, finalized, safe, earliest) and timestamp a UNIX time")
+	ErrBeforeGenesis   = errors.New("timestamp is before the first block")
+	ErrBlockNotFound   = errors.New("block not found")
+	errBlockAndTimeSet = errors.New("block and timestamp cannot be combined")
+	validTags          = map[string]bool{"latest": true, "finalized": true, "safe": true, "earliest": true}
+)
+
+// Spec selects the block a check runs at: an exact number, a tag or the last
+// block at or before a UNIX timestamp. The zero Spec is the latest block.
+type Spec struct {
+	Number    *big.Int
+	Tag       string
+	Timestamp *uint64
+}
+
+// Block is a resolved block, echoed in responses so results can be audited.
+type Block struct {
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`
+	Timestamp uint64      `json:"timestamp"`
+}
+
+// ParseSpec parses the block and timestamp query parameters. It returns nil if
+// both are empty.
+func ParseSpec(block, timestamp string) (*Spec, error) {
+	block = strings.ToLower(strings.TrimSpace(block))
+	timestamp = strings.TrimSpace(timestamp)
+
+	switch {
+	case block == "" && timestamp == "":
+		return nil, nil
+	case block != "" && timestamp != "":
+		return nil, errBlockAndTimeSet
+	case timestamp != "":
+		ts, err := strconv.ParseUint(timestamp, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidSpec
+		}
+		return &Spec{Timestamp: &ts}, nil
+	case validTags[block]:
+		return &Spec{Tag: block}, nil
+	}
+
+	number, ok := new(big.Int).SetString(block, 0)
+	if !ok || number.Sign() < 0 || !number.IsUint64() {
+		return nil, ErrInvalidSpec
+	}
+	return &Spec{Number: number}, nil
+}
+
+// header is the part of a block header needed to resolve a Spec. It is
+// decoded by hand as some EVM compatible chains omit fields that
+// go-ethereum's types.Header requires.
+type header struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// headerCall requests the header of a block by number or tag.
+type headerCall struct {
+	block  string
+	result json.RawMessage
+	header *Block
+}
+
+func headerAt(block string, returns *Block) *headerCall {
+	return &headerCall{block: block, header: returns}
+}
+
+func (hc *headerCall) CreateRequest() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: "eth_getBlockByNumber",
+		Args:   []any{hc.block, false},
+		Result: &hc.result,
+	}, nil
+}
+
+func (hc *headerCall) HandleResponse(elem rpc.BatchElem) error {
+	if elem.Error != nil {
+		return elem.Error
+	}
+	if len(hc.result) == 0 || string(hc.result) == "null" {
+		return fmt.Errorf("%w: %s", ErrBlockNotFound, hc.block)
+	}
+
+	var h header
+	if err := json.Unmarshal(hc.result, &h); err != nil {
+		return err
+	}
+	*hc.header = Block{Number: uint64(h.Number), Hash: h.Hash, Timestamp: uint64(h.Timestamp)}
+	return nil
+}
+
+// Resolve returns the block a Spec refers to on the network of client. A nil
+// Spec resolves to the latest block.
+func Resolve(ctx context.Context, client shared.RPCClient, spec *Spec) (*Block, error) {
+	tag := "latest"
+	switch {
+	case spec == nil:
+	case spec.Tag != "":
+		tag = spec.Tag
+	case spec.Number != nil:
+		tag = hexutil.EncodeBig(spec.Number)
+	case spec.Timestamp != nil:
+		return searchTimestamp(ctx, client, *spec.Timestamp)
+	default:
+		return nil, ErrInvalidSpec
+	}
+
+	var block Block
+	if err := client.CallCtx(ctx, headerAt(tag, &block)); err != nil {
+		return nil, callError(err)
+	}
+	return &block, nil
+}
+
+// callError returns the error of the first failed call of a batch, so errors
+// such as ErrBlockNotFound can be matched with errors.Is.
+func callError(err error) error {
+	var callErrs w3.CallErrors
+	if errors.As(err, &callErrs) {
+		for _, callErr := range callErrs {
+			if callErr != nil {
+				return callErr
+			}
+		}
+	}
+	return err
+}
+
+// searchTimestamp finds the last block with a timestamp at or before ts. Each
+// round fetches searchFanout headers in one batch and narrows the range
+// between them, so a chain of n blocks takes about log(n)/log(searchFanout)
+// round trips.
+func searchTimestamp(ctx context.Context, client shared.RPCClient, ts uint64) (*Block, error) {
+	var lo, hi Block
+	if err := client.CallCtx(ctx, headerAt("earliest", &lo), headerAt("latest", &hi)); err != nil {
+		return nil, callError(err)
+	}
+	if ts < lo.Timestamp {
+		return nil, ErrBeforeGenesis
+	}
+	if ts >= hi.Timestamp {
+		return &hi, nil
+	}
+
+	// Invariant: lo.Timestamp <= ts < hi.Timestamp
+	for hi.Number-lo.Number > 1 {
+		step := (hi.Number - lo.Number) / searchFanout
+		if step == 0 {
+			step = 1
+		}
+
+		var probes []*Block
+		var calls []w3types.Caller
+		for n := lo.Number + step; n < hi.Number; n += step {
+			probe := new(Block)
+			probes = append(probes, probe)
+			calls = append(calls, headerAt(hexutil.EncodeUint64(n), probe))
+		}
+		if err := client.CallCtx(ctx, calls...); err != nil {
+			return nil, callError(err)
+		}
+
+		for _, probe := range probes {
+			if probe.Timestamp <= ts {
+				lo = *probe
+			} else {
+				hi = *probe
+				break
+			}
+		}
+	}
+	return &lo, nil
+}
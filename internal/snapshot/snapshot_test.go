@@ -0,0 +1,134 @@
+package snapshot_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/snapshot"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/lmittmann/w3/w3types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockClient answers eth_getBlockByNumber for a chain of latest+1 blocks
+// with the given timestamps.
+type mockClient struct {
+	latest    uint64
+	timestamp func(n uint64) uint64
+	batches   int
+}
+
+func (m *mockClient) Call(calls ...w3types.Caller) error {
+	return m.CallCtx(context.Background(), calls...)
+}
+
+func (m *mockClient) CallCtx(ctx context.Context, calls ...w3types.Caller) error {
+	m.batches++
+	for _, call := range calls {
+		elem, err := call.CreateRequest()
+		if err != nil {
+			return err
+		}
+
+		var number uint64
+		switch tag := elem.Args[0].(string); tag {
+		case "latest":
+			number = m.latest
+		case "earliest":
+			number = 0
+		default:
+			number, err = hexutil.DecodeUint64(tag)
+			if err != nil {
+				return err
+			}
+		}
+
+		data := []byte("null")
+		if number <= m.latest {
+			data = []byte(fmt.Sprintf(`{"number":"%s","hash":"0x%064x","timestamp":"%s"}`,
+				hexutil.EncodeUint64(number), number, hexutil.EncodeUint64(m.timestamp(number))))
+		}
+		*elem.Result.(*json.RawMessage) = data
+		if err := call.HandleResponse(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockClient) Close() error { return nil }
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		block       string
+		timestamp   string
+		expected    *snapshot.Spec
+		expectError bool
+	}{
+		{"", "", nil, false},
+		{"123", "", &snapshot.Spec{Number: big.NewInt(123)}, false},
+		{"0x10", "", &snapshot.Spec{Number: big.NewInt(16)}, false},
+		{"Finalized", "", &snapshot.Spec{Tag: "finalized"}, false},
+		{"pending", "", nil, true},
+		{"-1", "", nil, true},
+		{"", "1700000000", &snapshot.Spec{Timestamp: new(uint64)}, false},
+		{"", "yesterday", nil, true},
+		{"1", "1700000000", nil, true},
+	}
+
+	for _, test := range tests {
+		spec, err := snapshot.ParseSpec(test.block, test.timestamp)
+		if (err != nil) != test.expectError {
+			t.Errorf("Test case %q/%q failed: expected error %v, but got %v", test.block, test.timestamp, test.expectError, err)
+			continue
+		}
+		if test.expected != nil && test.expected.Timestamp != nil {
+			assert.NotNil(t, spec.Timestamp, "Timestamp should be set")
+			continue
+		}
+		assert.Equal(t, test.expected, spec, "Spec should match for %q", test.block)
+	}
+}
+
+func TestResolveTimestamp(t *testing.T) {
+	// Block times vary between 2 and 13 seconds.
+	client := &mockClient{
+		latest:    1_000_000,
+		timestamp: func(n uint64) uint64 { return 1_600_000_000 + n*12 + n%7 - n/1000 },
+	}
+
+	for _, n := range []uint64{0, 1, 777, 123_456, 999_999, 1_000_000} {
+		ts := client.timestamp(n)
+		for _, offset := range []uint64{0, 1} {
+			client.batches = 0
+			block, err := snapshot.Resolve(context.Background(), client, &snapshot.Spec{Timestamp: &[]uint64{ts + offset}[0]})
+			assert.NoError(t, err, "Should not return an error")
+			assert.Equal(t, n, block.Number, "Timestamp %d should resolve to block %d", ts+offset, n)
+			assert.LessOrEqual(t, client.batches, 12, "Search should batch header requests")
+		}
+	}
+
+	// Test case: Before the first block
+	before := uint64(1)
+	_, err := snapshot.Resolve(context.Background(), client, &snapshot.Spec{Timestamp: &before})
+	assert.ErrorIs(t, err, snapshot.ErrBeforeGenesis, "Should return ErrBeforeGenesis")
+}
+
+func TestResolveNumber(t *testing.T) {
+	client := &mockClient{latest: 100, timestamp: func(n uint64) uint64 { return n }}
+
+	block, err := snapshot.Resolve(context.Background(), client, &snapshot.Spec{Number: big.NewInt(42)})
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, uint64(42), block.Number, "Block number should match")
+	assert.Equal(t, "0x000000000000000000000000000000000000000000000000000000000000002a", block.Hash.Hex(), "Hash should match")
+
+	block, err = snapshot.Resolve(context.Background(), client, nil)
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, uint64(100), block.Number, "Nil spec should resolve to latest")
+
+	_, err = snapshot.Resolve(context.Background(), client, &snapshot.Spec{Number: big.NewInt(101)})
+	assert.ErrorIs(t, err, snapshot.ErrBlockNotFound, "Should return ErrBlockNotFound")
+}
@@ -0,0 +1,178 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// Checker implements a token standard. A checker is registered under its
+// name and aliases, which are the values of the standard path segment.
+type Checker interface {
+	// Names returns the name of the standard followed by its aliases.
+	Names() []string
+
+	// NewCheck parses the amount of a check of contract on network and
+	// returns an error if the amount is not valid for the standard.
+	NewCheck(network, contract, amount string) (Check, error)
+}
+
+// Contractless is implemented by checkers that ignore the contract of a
+// check, such as native coin balances. The contract allowList does not apply
+// to them.
+type Contractless interface {
+	Contractless()
+}
+
+// Selective is implemented by checks whose results count only some tokens
+// of the contract without naming them, such as tokens with matching traits.
+// Linked accounts scoped to a token ID are not credited by them, as the
+// counted tokens may be others.
+type Selective interface {
+	Selective()
+}
+
+// Scaled is implemented by checks whose balances have decimals, such as
+// ERC-20 tokens. Decimals is valid once the calls have been sent.
+type Scaled interface {
+	Decimals() uint8
+}
+
+// Reverting is implemented by checks whose calls may revert without failing
+// the check, such as ownerOf of a token that was burned. AllowFailure reports
+// whether the i-th call of the last batch may revert; its result is then left
+// at the zero value. Calls that fail for other reasons, such as a timeout of
+// the node, fail the check.
+type Reverting interface {
+	AllowFailure(i int) bool
+}
+
+// Staged is implemented by checks that need the results of their calls to
+// make further calls, such as enumerating the tokens of a wallet before
+// fetching their metadata. Next is called after every batch until it
+// returns no calls; AllowFailure then refers to the calls it returned.
+type Staged interface {
+	Next(ctx context.Context) ([]w3types.Caller, error)
+}
+
+// Combiner is implemented by checks that are met by a combination of
+// balances rather than a single one, such as a full set of ERC-1155 token
+// IDs. Combine is applied to the results, or to their Sum, and returns one
+// result per address.
+type Combiner interface {
+	Combine(results []Result) []Result
+}
+
+// Check is a single balance check of a standard.
+type Check interface {
+	// Calls returns the calls fetching the balances of the addresses at
+	// blockNumber, or at the latest block if blockNumber is nil. The calls
+	// write their results into the Check.
+	Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller
+
+	// Results compares the fetched balances with the amount of the check.
+	Results() ([]Result, error)
+}
+
+// Result is one compared balance of a check. Balance and Threshold are in the
+// token's smallest unit; TokenID is nil for standards without token IDs.
+type Result struct {
+	Address   common.Address
+	TokenID   *big.Int
+	Balance   *big.Int
+	Threshold compare.Threshold
+	Met       bool
+}
+
+// Sum adds up the balances of all addresses, separately for every token ID,
+// and compares each sum with the threshold of the token ID. The returned
+// results have no address and follow the order of the first result of each
+// token ID.
+func Sum(results []Result) []Result {
+	var sums []Result
+	index := make(map[string]int)
+	for _, result := range results {
+		key := ""
+		if result.TokenID != nil {
+			key = result.TokenID.String()
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(sums)
+			index[key] = i
+			sums = append(sums, Result{TokenID: result.TokenID, Balance: new(big.Int), Threshold: result.Threshold})
+		}
+		if result.Balance != nil {
+			sums[i].Balance.Add(sums[i].Balance, result.Balance)
+		}
+	}
+	for i := range sums {
+		sums[i].Met = sums[i].Threshold.Met(sums[i].Balance)
+	}
+	return sums
+}
+
+var (
+	ErrMissingContract = errors.New("contract is required")
+	ErrUnsupported     = errors.New("contract does not support the check")
+)
+
+var (
+	mu       sync.RWMutex
+	checkers = make(map[string]Checker)
+)
+
+// Register makes a checker available under its names. It is meant to be
+// called from the init function of the package implementing the standard
+// and panics if a name is already registered.
+func Register(checker Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	names := checker.Names()
+	if len(names) == 0 {
+		panic("standard: checker without a name")
+	}
+	for _, name := range names {
+		if _, exists := checkers[name]; exists {
+			panic(fmt.Sprintf("standard: %s registered twice", name))
+		}
+	}
+	for _, name := range names {
+		checkers[name] = checker
+	}
+}
+
+// Lookup returns the checker registered under a name or alias.
+func Lookup(name string) (Checker, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	checker, ok := checkers[name]
+	return checker, ok
+}
+
+// Names returns the name and the aliases of every registered standard.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Same reports whether two names refer to the same registered standard.
+func Same(a, b string) bool {
+	checkerA, okA := Lookup(a)
+	checkerB, okB := Lookup(b)
+	return okA && okB && checkerA.Names()[0] == checkerB.Names()[0]
+}
@@ -0,0 +1,70 @@
+package standard
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3/w3types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChecker struct{ names []string }
+
+func (m mockChecker) Names() []string { return m.names }
+
+func (m mockChecker) NewCheck(network, contract, amount string) (Check, error) {
+	return mockCheck{}, nil
+}
+
+type mockCheck struct{}
+
+func (mockCheck) Calls(addresses []common.Address, blockNumber *big.Int) []w3types.Caller {
+	return nil
+}
+
+func (mockCheck) Results() ([]Result, error) { return nil, nil }
+
+func TestRegister(t *testing.T) {
+	Register(mockChecker{names: []string{"mock", "mk"}})
+
+	checker, ok := Lookup("mk")
+	assert.True(t, ok, "Alias should be registered")
+	assert.Equal(t, "mock", checker.Names()[0], "Alias should resolve to the standard")
+	assert.Contains(t, Names(), "mock")
+
+	_, ok = Lookup("unknown")
+	assert.False(t, ok, "Unknown standard should not be found")
+
+	assert.True(t, Same("mock", "mk"), "Name and alias should be the same standard")
+	assert.False(t, Same("mock", "unknown"), "Unknown standard should not match")
+
+	assert.Panics(t, func() { Register(mockChecker{names: []string{"other", "mk"}}) }, "Duplicate alias should panic")
+	_, ok = Lookup("other")
+	assert.False(t, ok, "Failed registration should not register any name")
+}
+
+func TestSum(t *testing.T) {
+	a, b := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	atLeast := func(n int64) compare.Threshold { return compare.AtLeast(big.NewInt(n)) }
+
+	sums := Sum([]Result{
+		{Address: a, TokenID: big.NewInt(1), Balance: big.NewInt(2), Threshold: atLeast(3)},
+		{Address: a, TokenID: big.NewInt(2), Balance: big.NewInt(1), Threshold: atLeast(2)},
+		{Address: b, TokenID: big.NewInt(1), Balance: big.NewInt(1), Threshold: atLeast(3)},
+		{Address: b, TokenID: big.NewInt(2), Balance: nil, Threshold: atLeast(2)},
+	})
+	assert.Len(t, sums, 2, "Should sum per token ID")
+	assert.Equal(t, big.NewInt(3), sums[0].Balance)
+	assert.True(t, sums[0].Met, "Token 1 should meet the threshold")
+	assert.Equal(t, big.NewInt(1), sums[1].Balance)
+	assert.False(t, sums[1].Met, "Token 2 should not meet the threshold")
+
+	sums = Sum([]Result{
+		{Address: a, Balance: big.NewInt(600), Threshold: atLeast(1000)},
+		{Address: b, Balance: big.NewInt(500), Threshold: atLeast(1000)},
+	})
+	assert.Len(t, sums, 1, "Should sum balances without token ID")
+	assert.True(t, sums[0].Met)
+}
@@ -0,0 +1,398 @@
+package substrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/units"
+)
+
+// Standards of Substrate networks, the values of the standard path segment.
+const (
+	StandardNative  = "native"
+	StandardAssets  = "assets"
+	StandardNfts    = "nfts"
+	StandardUniques = "uniques"
+)
+
+const (
+	// idsPrefix marks an amount that selects items of a collection instead
+	// of a number of items, like ids: of ERC-721.
+	idsPrefix = "ids:"
+
+	// keysPageSize is the number of storage keys of a state_getKeysPaged
+	// call when the items of an account are counted.
+	keysPageSize = 1000
+)
+
+var (
+	ErrUnknownStandard = fmt.Errorf("standard must be one of %s, %s, %s or %s on Substrate networks", StandardNative, StandardAssets, StandardNfts, StandardUniques)
+	ErrInvalidID       = errors.New("asset and collection IDs must be u32 numbers")
+	ErrNotFound        = errors.New("asset or collection does not exist")
+	ErrInvalidItems    = errors.New("item IDs must be ids: followed by u32 IDs or ranges separated by commas, e.g. ids:1,5,10-20")
+)
+
+// pallet names the storage of a pallet holding collections of items.
+type pallet struct {
+	name       string
+	collection string
+	item       string
+	account    string
+}
+
+var (
+	palletNfts    = pallet{name: "Nfts", collection: "Collection", item: "Item", account: "Account"}
+	palletUniques = pallet{name: "Uniques", collection: "Class", item: "Asset", account: "Account"}
+)
+
+// Check is a balance check of a Substrate network.
+type Check interface {
+	// Results fetches the balances of the accounts at the latest block and
+	// compares them with the amount of the check.
+	Results(ctx context.Context, client *Client, accounts []AccountID) ([]Result, error)
+}
+
+// Result is one compared balance of a check. Balance and Threshold are in the
+// smallest unit of the token; Item is nil for checks without item IDs.
+type Result struct {
+	Account   AccountID
+	Item      *big.Int
+	Balance   *big.Int
+	Threshold compare.Threshold
+	Met       bool
+}
+
+// NewCheck parses a check of a standard. The contract is the asset ID of
+// assets and the collection ID of nfts and uniques, and is ignored by native.
+// Amounts of native and assets are decimal numbers of tokens, as for ERC-20;
+// amounts of nfts and uniques are a number of items or ids: followed by the
+// item IDs, as for ERC-721.
+func NewCheck(standard, contract, amount string, lim limits.Config) (Check, error) {
+	switch standard {
+	case StandardNative:
+		condition, err := parseCondition(amount)
+		if err != nil {
+			return nil, err
+		}
+		return &nativeCheck{condition: condition}, nil
+
+	case StandardAssets:
+		id, err := parseID(contract)
+		if err != nil {
+			return nil, err
+		}
+		condition, err := parseCondition(amount)
+		if err != nil {
+			return nil, err
+		}
+		return &assetCheck{id: id, condition: condition}, nil
+
+	case StandardNfts, StandardUniques:
+		p := palletNfts
+		if standard == StandardUniques {
+			p = palletUniques
+		}
+		id, err := parseID(contract)
+		if err != nil {
+			return nil, err
+		}
+		if selector, ok := strings.CutPrefix(amount, idsPrefix); ok {
+			items, err := parseItems(selector, lim)
+			if err != nil {
+				return nil, err
+			}
+			return &ownerCheck{pallet: p, collection: id, items: items}, nil
+		}
+		condition, err := compare.Parse(amount)
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := condition.Convert(compare.Integer)
+		if err != nil {
+			return nil, err
+		}
+		return &countCheck{pallet: p, collection: id, threshold: threshold}, nil
+	}
+	return nil, fmt.Errorf("%w, got %q", ErrUnknownStandard, standard)
+}
+
+// IsStandard reports whether name is a standard of Substrate networks.
+func IsStandard(name string) bool {
+	switch name {
+	case StandardNative, StandardAssets, StandardNfts, StandardUniques:
+		return true
+	}
+	return false
+}
+
+// parseCondition parses a decimal amount before the decimals are known.
+func parseCondition(amount string) (compare.Condition, error) {
+	condition, err := compare.Parse(amount)
+	if err != nil {
+		return condition, err
+	}
+	_, err = condition.Convert(func(amount string) (*big.Int, error) {
+		_, err := units.Parse(amount)
+		return new(big.Int), err
+	})
+	return condition, err
+}
+
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w, got %q", ErrInvalidID, s)
+	}
+	return uint32(id), nil
+}
+
+// parseItems parses the selector after ids:. Ranges include both ends and
+// duplicates are checked once, bounded by maxTokenIds of the limits.
+func parseItems(selector string, lim limits.Config) ([]uint32, error) {
+	var items []uint32
+	seen := make(map[uint32]bool)
+	for _, part := range strings.Split(selector, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.ParseUint(first, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidItems, part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.ParseUint(last, 10, 32); err != nil || start > end {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidItems, part)
+			}
+		}
+		for item := start; item <= end; item++ {
+			if seen[uint32(item)] {
+				continue
+			}
+			if err := lim.TokenIDs(len(items) + 1); err != nil {
+				return nil, err
+			}
+			seen[uint32(item)] = true
+			items = append(items, uint32(item))
+		}
+	}
+	return items, nil
+}
+
+func decimal(decimals uint8) func(string) (*big.Int, error) {
+	return func(amount string) (*big.Int, error) {
+		return units.ParseDecimal(amount, decimals)
+	}
+}
+
+func compareBalances(accounts []AccountID, balances []*big.Int, threshold compare.Threshold) []Result {
+	results := make([]Result, len(accounts))
+	for i, account := range accounts {
+		results[i] = Result{
+			Account:   account,
+			Balance:   balances[i],
+			Threshold: threshold,
+			Met:       threshold.Met(balances[i]),
+		}
+	}
+	return results
+}
+
+// nativeCheck checks the free balance of System.Account, in the decimals of
+// system_properties.
+type nativeCheck struct {
+	condition compare.Condition
+}
+
+func (c *nativeCheck) Results(ctx context.Context, client *Client, accounts []AccountID) ([]Result, error) {
+	properties, err := client.Properties(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := c.condition.Convert(decimal(properties.TokenDecimals))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]byte, len(accounts))
+	for i, account := range accounts {
+		keys[i] = StorageKey("System", "Account", account[:])
+	}
+	values, err := client.Storage(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*big.Int, len(accounts))
+	for i, value := range values {
+		balances[i] = new(big.Int)
+		if value == nil {
+			continue
+		}
+		// AccountInfo starts with nonce, consumers, providers and
+		// sufficients, followed by the free balance.
+		d := decoder{data: value}
+		d.bytes(16)
+		balances[i] = d.u128()
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: System.Account: %v", ErrRPC, d.err)
+		}
+	}
+	return compareBalances(accounts, balances, threshold), nil
+}
+
+// assetCheck checks the balance of an asset of the assets pallet, in the
+// decimals of its metadata.
+type assetCheck struct {
+	id        uint32
+	condition compare.Condition
+}
+
+func (c *assetCheck) Results(ctx context.Context, client *Client, accounts []AccountID) ([]Result, error) {
+	id := U32(c.id)
+	keys := [][]byte{
+		StorageKey("Assets", "Asset", id),
+		StorageKey("Assets", "Metadata", id),
+	}
+	for _, account := range accounts {
+		keys = append(keys, StorageKey("Assets", "Account", id, account[:]))
+	}
+	values, err := client.Storage(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == nil {
+		return nil, fmt.Errorf("%w: asset %d", ErrNotFound, c.id)
+	}
+
+	// Assets without metadata have no decimals.
+	var decimals uint8
+	if values[1] != nil {
+		d := decoder{data: values[1]}
+		d.u128()
+		d.skipVec()
+		d.skipVec()
+		decimals = d.u8()
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: Assets.Metadata: %v", ErrRPC, d.err)
+		}
+	}
+	threshold, err := c.condition.Convert(decimal(decimals))
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*big.Int, len(accounts))
+	for i, value := range values[2:] {
+		balances[i] = new(big.Int)
+		if value == nil {
+			continue
+		}
+		d := decoder{data: value}
+		balances[i] = d.u128()
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: Assets.Account: %v", ErrRPC, d.err)
+		}
+	}
+	return compareBalances(accounts, balances, threshold), nil
+}
+
+// countCheck counts the items of a collection that an account owns, from the
+// keys of its entries in the Account storage of the pallet.
+type countCheck struct {
+	pallet     pallet
+	collection uint32
+	threshold  compare.Threshold
+}
+
+func (c *countCheck) Results(ctx context.Context, client *Client, accounts []AccountID) ([]Result, error) {
+	collection := U32(c.collection)
+	values, err := client.Storage(ctx, [][]byte{StorageKey(c.pallet.name, c.pallet.collection, collection)})
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == nil {
+		return nil, fmt.Errorf("%w: collection %d", ErrNotFound, c.collection)
+	}
+
+	balances := make([]*big.Int, len(accounts))
+	for i, account := range accounts {
+		owned := StorageKey(c.pallet.name, c.pallet.account, account[:], collection)
+		count := 0
+		var start []byte
+		for {
+			keys, err := client.KeysPaged(ctx, owned, keysPageSize, start)
+			if err != nil {
+				return nil, err
+			}
+			count += len(keys)
+			if len(keys) < keysPageSize {
+				break
+			}
+			start = keys[len(keys)-1]
+		}
+		balances[i] = big.NewInt(int64(count))
+	}
+	return compareBalances(accounts, balances, c.threshold), nil
+}
+
+// ownerCheck checks that an account owns any of the selected items. The
+// owner of every item is read once and compared with all accounts; items
+// that do not exist are owned by nobody.
+type ownerCheck struct {
+	pallet     pallet
+	collection uint32
+	items      []uint32
+}
+
+func (c *ownerCheck) Results(ctx context.Context, client *Client, accounts []AccountID) ([]Result, error) {
+	collection := U32(c.collection)
+	keys := [][]byte{StorageKey(c.pallet.name, c.pallet.collection, collection)}
+	for _, item := range c.items {
+		keys = append(keys, StorageKey(c.pallet.name, c.pallet.item, collection, U32(item)))
+	}
+	values, err := client.Storage(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == nil {
+		return nil, fmt.Errorf("%w: collection %d", ErrNotFound, c.collection)
+	}
+
+	// The details of an item start with its owner.
+	owners := make([]*AccountID, len(c.items))
+	for i, value := range values[1:] {
+		if value == nil {
+			continue
+		}
+		d := decoder{data: value}
+		owner := d.bytes(len(AccountID{}))
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: %s.%s: %v", ErrRPC, c.pallet.name, c.pallet.item, d.err)
+		}
+		owners[i] = (*AccountID)(owner)
+	}
+
+	threshold := compare.AtLeast(big.NewInt(1))
+	results := make([]Result, 0, len(accounts)*len(c.items))
+	for _, account := range accounts {
+		for i, item := range c.items {
+			balance := new(big.Int)
+			if owners[i] != nil && *owners[i] == account {
+				balance.SetInt64(1)
+			}
+			results = append(results, Result{
+				Account:   account,
+				Item:      new(big.Int).SetUint64(uint64(item)),
+				Balance:   balance,
+				Threshold: threshold,
+				Met:       threshold.Met(balance),
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,272 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/limits"
+)
+
+// DefaultTimeout bounds a request to a node.
+const DefaultTimeout = 10 * time.Second
+
+// ErrRPC wraps every error of a node, whether it could not be reached or
+// answered with an error.
+var ErrRPC = errors.New("substrate RPC failed")
+
+// Client is a JSON-RPC client of a Substrate node over HTTP. Storage queries
+// spend the call budget of the request, see limits.SpendCalls.
+type Client struct {
+	url       string
+	http      *http.Client
+	batchSize int
+
+	mu         sync.Mutex
+	properties *Properties
+}
+
+// NewClient returns a client of the node at url that sends storage queries in
+// batches of batchSize, or limits.DefaultRPCBatchSize if batchSize is 0.
+func NewClient(url string, batchSize int) *Client {
+	if batchSize <= 0 {
+		batchSize = limits.DefaultRPCBatchSize
+	}
+	return &Client{
+		url:       url,
+		http:      &http.Client{Timeout: DefaultTimeout},
+		batchSize: batchSize,
+	}
+}
+
+// URL returns the URL of the node.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Properties are the chain properties of system_properties.
+type Properties struct {
+	SS58Format    uint16
+	TokenDecimals uint8
+	TokenSymbol   string
+}
+
+// Properties returns the chain properties, which are fetched once. Chains
+// with several tokens list the native token first. Concurrent first calls
+// may fetch them more than once.
+func (c *Client) Properties(ctx context.Context) (Properties, error) {
+	c.mu.Lock()
+	cached := c.properties
+	c.mu.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	var raw struct {
+		SS58Format    uint16          `json:"ss58Format"`
+		TokenDecimals json.RawMessage `json:"tokenDecimals"`
+		TokenSymbol   json.RawMessage `json:"tokenSymbol"`
+	}
+	if err := c.call(ctx, "system_properties", nil, &raw); err != nil {
+		return Properties{}, err
+	}
+	properties := Properties{SS58Format: raw.SS58Format}
+	if err := first(raw.TokenDecimals, &properties.TokenDecimals); err != nil {
+		return Properties{}, fmt.Errorf("%w: tokenDecimals: %v", ErrRPC, err)
+	}
+	if err := first(raw.TokenSymbol, &properties.TokenSymbol); err != nil {
+		return Properties{}, fmt.Errorf("%w: tokenSymbol: %v", ErrRPC, err)
+	}
+	c.mu.Lock()
+	c.properties = &properties
+	c.mu.Unlock()
+	return properties, nil
+}
+
+// first decodes a property that is a value, or a list of values of which the
+// first is used. Missing properties keep the zero value.
+func first[T any](raw json.RawMessage, value *T) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] != '[' {
+		return json.Unmarshal(raw, value)
+	}
+	var values []T
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return err
+	}
+	if len(values) > 0 {
+		*value = values[0]
+	}
+	return nil
+}
+
+// Storage returns the values of the storage keys at the latest block, nil for
+// keys without a value. Each key is one call of the budget.
+func (c *Client) Storage(ctx context.Context, keys [][]byte) ([][]byte, error) {
+	if err := limits.SpendCalls(ctx, len(keys)); err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for start := 0; start < len(keys); start += c.batchSize {
+		end := min(start+c.batchSize, len(keys))
+		requests := make([]request, 0, end-start)
+		for _, key := range keys[start:end] {
+			requests = append(requests, request{Method: "state_getStorage", Params: []any{hexKey(key)}})
+		}
+		results, err := c.batch(ctx, requests)
+		if err != nil {
+			return nil, err
+		}
+		for i, result := range results {
+			if values[start+i], err = decodeHex(result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// KeysPaged returns up to count storage keys with the prefix that follow
+// startKey, or the first ones if startKey is nil. It is one call of the
+// budget.
+func (c *Client) KeysPaged(ctx context.Context, prefix []byte, count int, startKey []byte) ([][]byte, error) {
+	if err := limits.SpendCalls(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	params := []any{hexKey(prefix), count}
+	if startKey != nil {
+		params = append(params, hexKey(startKey))
+	}
+	var result []string
+	if err := c.call(ctx, "state_getKeysPaged", params, &result); err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, len(result))
+	for i, key := range result {
+		var err error
+		if keys[i], err = hex.DecodeString(strings.TrimPrefix(key, "0x")); err != nil {
+			return nil, fmt.Errorf("%w: invalid key %q", ErrRPC, key)
+		}
+	}
+	return keys, nil
+}
+
+type request struct {
+	Method string
+	Params []any
+}
+
+type rpcMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call sends a single request and decodes its result.
+func (c *Client) call(ctx context.Context, method string, params []any, result any) error {
+	results, err := c.batch(ctx, []request{{Method: method, Params: params}})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(results[0], result); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRPC, method, err)
+	}
+	return nil
+}
+
+// batch sends the requests in one JSON-RPC batch and returns their results in
+// order. A failed request fails the batch.
+func (c *Client) batch(ctx context.Context, requests []request) ([]json.RawMessage, error) {
+	messages := make([]rpcMessage, len(requests))
+	for i, r := range requests {
+		params := r.Params
+		if params == nil {
+			params = []any{}
+		}
+		messages[i] = rpcMessage{JSONRPC: "2.0", ID: i, Method: r.Method, Params: params}
+	}
+	body, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRPC, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRPC, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s answered %s", ErrRPC, c.url, resp.Status)
+	}
+
+	var responses []rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRPC, err)
+	}
+	results := make([]json.RawMessage, len(requests))
+	answered := 0
+	for _, response := range responses {
+		if response.ID < 0 || response.ID >= len(requests) {
+			continue
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("%w: %s: %s (%d)", ErrRPC, requests[response.ID].Method, response.Error.Message, response.Error.Code)
+		}
+		results[response.ID] = response.Result
+		answered++
+	}
+	if answered != len(requests) {
+		return nil, fmt.Errorf("%w: %d of %d requests answered", ErrRPC, answered, len(requests))
+	}
+	return results, nil
+}
+
+func hexKey(key []byte) string {
+	return "0x" + hex.EncodeToString(key)
+}
+
+// decodeHex decodes a hex encoded storage value, or null.
+func decodeHex(raw json.RawMessage) ([]byte, error) {
+	var value *string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRPC, err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(*value, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid storage value %q", ErrRPC, *value)
+	}
+	if data == nil {
+		// Empty values, such as the () of NFT ownership, exist.
+		data = []byte{}
+	}
+	return data, nil
+}
@@ -0,0 +1,115 @@
+package substrate
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Storage keys are built from the hashers of FRAME: the pallet and item names
+// are hashed with twox128, map keys with blake2_128_concat.
+
+// StorageKey returns the storage key of an item of a pallet, such as System
+// Account, for the SCALE encoded parts of its map key. Keys with only the
+// first parts of a map key are the prefix of the entries that start with them.
+func StorageKey(pallet, item string, parts ...[]byte) []byte {
+	key := storagePrefix(pallet, item)
+	for _, part := range parts {
+		key = append(key, blake2128Concat(part)...)
+	}
+	return key
+}
+
+// storagePrefix returns the key prefix of a storage item of a pallet.
+func storagePrefix(pallet, item string) []byte {
+	return append(twox128([]byte(pallet)), twox128([]byte(item))...)
+}
+
+// twox128 is two xxHash64 hashes of data, seeded 0 and 1, little endian.
+func twox128(data []byte) []byte {
+	out := make([]byte, 16)
+	binary.LittleEndian.PutUint64(out, xxhash64(data, 0))
+	binary.LittleEndian.PutUint64(out[8:], xxhash64(data, 1))
+	return out
+}
+
+// blake2128Concat is the blake2b-128 hash of data followed by data, so map
+// keys can be read back from storage keys.
+func blake2128Concat(data []byte) []byte {
+	h, _ := blake2b.New(16, nil)
+	h.Write(data)
+	return append(h.Sum(nil), data...)
+}
+
+// U32 SCALE encodes an asset, collection or item ID.
+func U32(n uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, n)
+}
+
+const (
+	prime1 uint64 = 11400714785074694791
+	prime2 uint64 = 14029467366897019727
+	prime3 uint64 = 1609587929392839161
+	prime4 uint64 = 9650029242287828579
+	prime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 is xxHash64 with a seed, which github.com/cespare/xxhash does not
+// support.
+func xxhash64(b []byte, seed uint64) uint64 {
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		v1 := seed + prime1 + prime2
+		v2 := seed + prime2
+		v3 := seed
+		v4 := seed - prime1
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = round(v1, binary.LittleEndian.Uint64(b))
+			v2 = round(v2, binary.LittleEndian.Uint64(b[8:]))
+			v3 = round(v3, binary.LittleEndian.Uint64(b[16:]))
+			v4 = round(v4, binary.LittleEndian.Uint64(b[24:]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = mergeRound(h, v1)
+		h = mergeRound(h, v2)
+		h = mergeRound(h, v3)
+		h = mergeRound(h, v4)
+	} else {
+		h = seed + prime5
+	}
+
+	h += uint64(n)
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= round(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*prime1 + prime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * prime1
+		h = bits.RotateLeft64(h, 23)*prime2 + prime3
+		b = b[4:]
+	}
+	for ; len(b) > 0; b = b[1:] {
+		h ^= uint64(b[0]) * prime5
+		h = bits.RotateLeft64(h, 11) * prime1
+	}
+
+	h ^= h >> 33
+	h *= prime2
+	h ^= h >> 29
+	h *= prime3
+	h ^= h >> 32
+	return h
+}
+
+func round(acc, input uint64) uint64 {
+	acc += input * prime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime1
+}
+
+func mergeRound(acc, val uint64) uint64 {
+	acc ^= round(0, val)
+	return acc*prime1 + prime4
+}
@@ -0,0 +1,83 @@
+package substrate
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Storage values are SCALE encoded. Only the parts of the values the checks
+// read are decoded.
+
+var errShortValue = errors.New("storage value is too short")
+
+// decoder reads SCALE values from the start of a storage value.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.data) {
+		d.err = errShortValue
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+// u128 reads a little endian u128, such as a balance.
+func (d *decoder) u128() *big.Int {
+	b := d.bytes(16)
+	if b == nil {
+		return nil
+	}
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+func (d *decoder) u8() uint8 {
+	b := d.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// compact reads a compact encoded integer, such as the length of a vector.
+// Lengths do not need the big integer mode.
+func (d *decoder) compact() int {
+	first := d.bytes(1)
+	if first == nil {
+		return 0
+	}
+	switch first[0] & 0b11 {
+	case 0b00:
+		return int(first[0] >> 2)
+	case 0b01:
+		rest := d.bytes(1)
+		if rest == nil {
+			return 0
+		}
+		return int(first[0])>>2 | int(rest[0])<<6
+	case 0b10:
+		rest := d.bytes(3)
+		if rest == nil {
+			return 0
+		}
+		return int(first[0])>>2 | int(rest[0])<<6 | int(rest[1])<<14 | int(rest[2])<<22
+	}
+	d.err = errors.New("compact integer is too large for a length")
+	return 0
+}
+
+// skipVec skips a vector of bytes, such as a name.
+func (d *decoder) skipVec() {
+	d.bytes(d.compact())
+}
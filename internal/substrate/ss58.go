@@ -0,0 +1,108 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var ErrInvalidAccount = errors.New("account must be an SS58 address or a 32-byte hex account ID")
+
+// AccountID is the 32-byte account ID of a Substrate account.
+type AccountID [32]byte
+
+// ParseAccount parses an SS58 address of any network, or a 0x-prefixed
+// 32-byte account ID.
+func ParseAccount(s string) (AccountID, error) {
+	var id AccountID
+	if hexID, ok := strings.CutPrefix(s, "0x"); ok {
+		data, err := hex.DecodeString(hexID)
+		if err != nil || len(data) != len(id) {
+			return id, fmt.Errorf("%w: %q", ErrInvalidAccount, s)
+		}
+		copy(id[:], data)
+		return id, nil
+	}
+
+	data, err := base58Decode(s)
+	if err != nil || len(data) < 1 {
+		return id, fmt.Errorf("%w: %q", ErrInvalidAccount, s)
+	}
+	prefixLen := 1
+	if data[0] >= 64 {
+		prefixLen = 2
+	}
+	if data[0] >= 128 || len(data) != prefixLen+len(id)+2 {
+		return id, fmt.Errorf("%w: %q", ErrInvalidAccount, s)
+	}
+	body := data[:len(data)-2]
+	if !bytes.Equal(ss58Checksum(body), data[len(data)-2:]) {
+		return id, fmt.Errorf("%w: bad checksum in %q", ErrInvalidAccount, s)
+	}
+	copy(id[:], body[prefixLen:])
+	return id, nil
+}
+
+// SS58 encodes the account ID as an SS58 address with the address format of
+// a network, such as 2 for Kusama.
+func (id AccountID) SS58(format uint16) string {
+	var body []byte
+	if format < 64 {
+		body = []byte{byte(format)}
+	} else {
+		body = []byte{
+			byte(format&0b1111_1100)>>2 | 0b0100_0000,
+			byte(format>>8) | byte(format&0b11)<<6,
+		}
+	}
+	body = append(body, id[:]...)
+	return base58Encode(append(body, ss58Checksum(body)...))
+}
+
+func ss58Checksum(body []byte) []byte {
+	sum := blake2b.Sum512(append([]byte("SS58PRE"), body...))
+	return sum[:2]
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	leading := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, leading), n.Bytes()...), nil
+}
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.QuoRem(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
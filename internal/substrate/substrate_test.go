@@ -0,0 +1,102 @@
+package substrate
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/compare"
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/units"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	alice    = "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"
+	aliceHex = "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+)
+
+func TestHashers(t *testing.T) {
+	// Known answers of XXH64 with seed 0, covering inputs shorter and longer
+	// than a 32 byte stripe.
+	for _, test := range []struct {
+		input string
+		hash  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"System", 0xe03056ea4e39aa26},
+		{"0123456789abcdef0123456789abcdef", 0x642a94958e71e6c5},
+		{"a longer input of more than thirty-two bytes in total", 0x2d3839e35fd5980b},
+	} {
+		assert.Equal(t, test.hash, xxhash64([]byte(test.input), 0), "Seed 0 should match XXH64 for %q", test.input)
+	}
+
+	assert.Equal(t, "26aa394eea5630e07c48ae0c9558cef7", hex.EncodeToString(twox128([]byte("System"))))
+	assert.Equal(t, "26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9", hex.EncodeToString(storagePrefix("System", "Account")))
+
+	id, err := ParseAccount(alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "de1e86a9a8c739864cf3cc5ec2bea59f"+aliceHex[2:], hex.EncodeToString(blake2128Concat(id[:])))
+}
+
+func TestParseAccount(t *testing.T) {
+	id, err := ParseAccount(alice)
+	assert.NoError(t, err)
+	assert.Equal(t, aliceHex, "0x"+hex.EncodeToString(id[:]))
+
+	fromHex, err := ParseAccount(aliceHex)
+	assert.NoError(t, err)
+	assert.Equal(t, id, fromHex, "Hex account IDs should parse")
+
+	assert.Equal(t, alice, id.SS58(42), "Encoding should round trip")
+	for _, format := range []uint16{0, 2, 63, 64, 255, 1284, 16383} {
+		parsed, err := ParseAccount(id.SS58(format))
+		assert.NoError(t, err, "Format %d should parse", format)
+		assert.Equal(t, id, parsed, "Format %d should round trip", format)
+	}
+
+	for _, invalid := range []string{"", "0x1234", "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQZ", "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQ0", "0x000000000000000000000000000000000000000A"} {
+		_, err := ParseAccount(invalid)
+		assert.ErrorIs(t, err, ErrInvalidAccount, "%q should be rejected", invalid)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	d := decoder{data: []byte{0x04, 0x15, 0x01, 0x02, 0x00, 0x01, 0x00}}
+	assert.Equal(t, 1, d.compact())
+	assert.Equal(t, 69, d.compact())
+	assert.Equal(t, 1<<14, d.compact())
+	assert.NoError(t, d.err)
+
+	d = decoder{data: []byte{0x01}}
+	d.u128()
+	assert.ErrorIs(t, d.err, errShortValue)
+}
+
+func TestNewCheck(t *testing.T) {
+	lim := limits.Config{}.WithDefaults()
+	tests := []struct {
+		standard, contract, amount string
+		err                        error
+	}{
+		{StandardNative, "", "0.5", nil},
+		{StandardNative, "", "abc", units.ErrInvalidDecimal},
+		{StandardAssets, "1984", "between:1:2", nil},
+		{StandardAssets, "", "1", ErrInvalidID},
+		{StandardAssets, "4294967296", "1", ErrInvalidID},
+		{StandardNfts, "7", "2", nil},
+		{StandardNfts, "7", "0.5", compare.ErrInvalidInteger},
+		{StandardUniques, "7", "ids:1,5-9", nil},
+		{StandardUniques, "7", "ids:9-5", ErrInvalidItems},
+		{StandardUniques, "7", "ids:0-4294967295", limits.ErrTooManyTokenIDs},
+		{"erc20", "7", "1", ErrUnknownStandard},
+	}
+	for _, test := range tests {
+		_, err := NewCheck(test.standard, test.contract, test.amount, lim)
+		if test.err == nil {
+			assert.NoError(t, err, "%s/%s/%s", test.standard, test.amount, test.contract)
+		} else {
+			assert.ErrorIs(t, err, test.err, "%s/%s/%s", test.standard, test.amount, test.contract)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package trn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/limits"
+	"github.com/FN00EU/vulcan-one/internal/linked"
+	"github.com/FN00EU/vulcan-one/internal/revert"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const (
+	SourceFuturePass = "futurepass"
+
+	// DefaultFuturePassTTL is how long, in seconds, FuturePass lookups are
+	// cached.
+	DefaultFuturePassTTL = 300
+
+	// maxCachedFuturePasses bounds the entries of each FuturePass cache.
+	maxCachedFuturePasses = 100000
+)
+
+var (
+	fpContractAddress = w3.A("0x000000000000000000000000000000000000FFFF")
+
+	// futurePassPrefix starts the address of every FuturePass.
+	futurePassPrefix = []byte{0xff, 0xff, 0xff, 0xff}
+
+	funcFuturePassOf = w3.MustNewFunc("futurepassOf(address)", "address")
+	funcOwner        = w3.MustNewFunc("owner()", "address")
+
+	ErrFuturePassLookup = errors.New("FuturePass lookup failed")
+	ErrNotFuturePass    = errors.New("address is not a FuturePass")
+	ErrNoFuturePass     = errors.New("address has no FuturePass")
+)
+
+func init() {
+	linked.Register(SourceFuturePass, func(config linked.Config) (linked.LinkedAccountResolver, error) {
+		return NewFuturePassResolver(config.CacheTTL), nil
+	})
+}
+
+// IsFuturePass reports whether an address is in the range of FuturePass
+// addresses.
+func IsFuturePass(address common.Address) bool {
+	return bytes.HasPrefix(address[:], futurePassPrefix)
+}
+
+// FuturePassResolver resolves the FuturePass of every wallet that has one
+// through the FuturePass registry precompile, and the owner of a FuturePass
+// through the FuturePass itself. Lookups at the latest block, including
+// wallets without a FuturePass, are cached for the TTL.
+type FuturePassResolver struct {
+	futurePasses *addressCache
+	owners       *addressCache
+}
+
+// NewFuturePassResolver returns a resolver caching lookups for ttl seconds,
+// or DefaultFuturePassTTL if ttl is 0.
+func NewFuturePassResolver(ttl int) *FuturePassResolver {
+	if ttl <= 0 {
+		ttl = DefaultFuturePassTTL
+	}
+	return &FuturePassResolver{
+		futurePasses: newAddressCache(time.Duration(ttl) * time.Second),
+		owners:       newAddressCache(time.Duration(ttl) * time.Second),
+	}
+}
+
+func (r *FuturePassResolver) Source() string {
+	return SourceFuturePass
+}
+
+func (r *FuturePassResolver) Resolve(ctx context.Context, client linked.Client, owners []common.Address, blockNumber *big.Int) ([]linked.Account, error) {
+	futurePasses, err := r.FuturePassesOf(ctx, client, owners, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []linked.Account
+	for i, futurePass := range futurePasses {
+		if futurePass != (common.Address{}) {
+			accounts = append(accounts, linked.Account{Address: futurePass, Owner: owners[i], Source: SourceFuturePass})
+		}
+	}
+	return accounts, nil
+}
+
+// FuturePassesOf returns the FuturePass of every owner, or the zero address
+// for owners without one, at blockNumber or the latest block if blockNumber
+// is nil. Only uncached owners are looked up, in one batch.
+func (r *FuturePassResolver) FuturePassesOf(ctx context.Context, client linked.Client, owners []common.Address, blockNumber *big.Int) ([]common.Address, error) {
+	futurePasses := make([]common.Address, len(owners))
+	var missing []int
+	for i, owner := range owners {
+		if blockNumber == nil {
+			if futurePass, ok := r.futurePasses.get(owner); ok {
+				futurePasses[i] = futurePass
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return futurePasses, nil
+	}
+
+	if err := limits.SpendCalls(ctx, len(missing)); err != nil {
+		return nil, err
+	}
+	calls := make([]w3types.Caller, len(missing))
+	for j, i := range missing {
+		calls[j] = eth.CallFunc(fpContractAddress, funcFuturePassOf, owners[i]).AtBlock(blockNumber).Returns(&futurePasses[i])
+	}
+	if err := client.CallCtx(ctx, calls...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFuturePassLookup, err)
+	}
+
+	if blockNumber == nil {
+		for _, i := range missing {
+			r.futurePasses.put(owners[i], futurePasses[i])
+		}
+	}
+	return futurePasses, nil
+}
+
+// OwnerOf returns the owner of a FuturePass at the latest block.
+func (r *FuturePassResolver) OwnerOf(ctx context.Context, client linked.Client, futurePass common.Address) (common.Address, error) {
+	if !IsFuturePass(futurePass) {
+		return common.Address{}, fmt.Errorf("%w: %s", ErrNotFuturePass, futurePass.Hex())
+	}
+	if owner, ok := r.owners.get(futurePass); ok {
+		return owner, nil
+	}
+
+	var (
+		owner  common.Address
+		output []byte
+	)
+	err := client.CallCtx(ctx, eth.Call(&w3types.Message{To: &futurePass, Func: funcOwner}, nil, nil).Returns(&output))
+	switch {
+	case revert.Only(err), err == nil && len(output) == 0:
+		// FuturePasses that were never created have no code, so the call
+		// reverts or returns nothing.
+	case err != nil:
+		return common.Address{}, fmt.Errorf("%w: %w", ErrFuturePassLookup, err)
+	default:
+		if err := funcOwner.DecodeReturns(output, &owner); err != nil {
+			return common.Address{}, fmt.Errorf("%w: %w", ErrFuturePassLookup, err)
+		}
+	}
+
+	r.owners.put(futurePass, owner)
+	return owner, nil
+}
+
+// addressCache maps addresses to addresses for a TTL.
+type addressCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[common.Address]cacheEntry
+}
+
+type cacheEntry struct {
+	address common.Address
+	expires time.Time
+}
+
+func newAddressCache(ttl time.Duration) *addressCache {
+	return &addressCache{ttl: ttl, entries: make(map[common.Address]cacheEntry)}
+}
+
+func (c *addressCache) get(key common.Address) (common.Address, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return common.Address{}, false
+	}
+	return entry.address, true
+}
+
+func (c *addressCache) put(key, address common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxCachedFuturePasses {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	// Still full, drop an arbitrary entry.
+	for k := range c.entries {
+		if len(c.entries) < maxCachedFuturePasses {
+			break
+		}
+		delete(c.entries, k)
+	}
+	c.entries[key] = cacheEntry{address: address, expires: now.Add(c.ttl)}
+}
@@ -1,65 +1,134 @@
 package trn
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"math/big"
-	"strings"
+	"strconv"
+	"sync"
 
+	"github.com/FN00EU/vulcan-one/internal/revert"
+	"github.com/FN00EU/vulcan-one/internal/shared"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
 	"github.com/lmittmann/w3/module/eth"
 	"github.com/lmittmann/w3/w3types"
 )
 
+const (
+	StandardERC721  = "erc721"
+	StandardERC1155 = "erc1155"
+)
+
 var (
-	fpContractAddress = w3.A("0x000000000000000000000000000000000000FFFF")
-	addressNull       = "0x0000000000000000000000000000000000000000"
+	funcSupportsInterface = w3.MustNewFunc("supportsInterface(bytes4)", "bool")
+	interfaceERC721       = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	interfaceERC1155      = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+
+	ErrInvalidID          = errors.New("asset and collection IDs must be integers from 0 to 4294967295")
+	ErrCollectionNotFound = errors.New("no NFT or SFT collection with this ID")
 )
 
-func AddFuturePasses(addresses []string, client w3.Client) []string {
-	var callRequests []w3types.Caller
-	fp := make([]*common.Address, len(addresses))
-	funcGetFuturePassOfEOA := w3.MustNewFunc("futurepassOf(address)", "address")
-	var fpAddresses []string
+// AssetIdToERC20Address returns the ERC-20 precompile of a native asset.
+func AssetIdToERC20Address(assetId string) (string, error) {
+	return precompileAddress("CCCCCCCC", assetId)
+}
 
-	for i, address := range addresses {
-		callRequests = append(callRequests, eth.CallFunc(fpContractAddress, funcGetFuturePassOfEOA, w3.A(address)).Returns(&fp[i]))
-	}
-	err := client.Call(callRequests...)
+// CollectionIdToERC721Address returns the ERC-721 precompile of a collection
+// of the NFT pallet.
+func CollectionIdToERC721Address(collectionId string) (string, error) {
+	return precompileAddress("AAAAAAAA", collectionId)
+}
+
+// CollectionIdToERC1155Address returns the ERC-1155 precompile of a
+// collection of the SFT pallet.
+func CollectionIdToERC1155Address(collectionId string) (string, error) {
+	return precompileAddress("BBBBBBBB", collectionId)
+}
+
+// precompileAddress places a u32 ID after the prefix of a precompile.
+func precompileAddress(prefix string, id string) (string, error) {
+	n, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		log.Println("Error:", err)
+		return "", fmt.Errorf("%w: %q", ErrInvalidID, id)
 	}
+	return common.HexToAddress(fmt.Sprintf("0x%s%08X000000000000000000000000", prefix, n)).Hex(), nil
+}
+
+type collectionKey struct {
+	network      string
+	collectionId string
+}
+
+// collections caches the standard of collections found by CollectionStandard.
+// A collection never moves between pallets, so entries do not expire.
+var collections sync.Map
 
-	for i, fpAddress := range fp {
-		log.Println(i)
-		if fpAddress.Hex() != addressNull {
-			fpAddresses = append(fpAddresses, fpAddress.Hex())
-		}
+// CollectionStandard returns the standard and precompile address of a
+// collection: erc721 for collections of the NFT pallet, erc1155 for those of
+// the SFT pallet. Both precompiles are asked whether they support their
+// interface; collections that do not exist revert or answer false.
+func CollectionStandard(ctx context.Context, network string, client shared.RPCClient, collectionId string) (string, string, error) {
+	key := collectionKey{network, collectionId}
+	if found, ok := collections.Load(key); ok {
+		standard := found.(string)
+		address, err := collectionAddress(standard, collectionId)
+		return standard, address, err
 	}
 
-	addresses = append(addresses, fpAddresses...)
+	erc721, err := CollectionIdToERC721Address(collectionId)
+	if err != nil {
+		return "", "", err
+	}
+	erc1155, _ := CollectionIdToERC1155Address(collectionId)
 
-	return addresses
-}
+	var out721, out1155 []byte
+	err = client.CallCtx(ctx,
+		eth.Call(&w3types.Message{To: w3.APtr(erc721), Func: funcSupportsInterface, Args: []any{interfaceERC721}}, nil, nil).Returns(&out721),
+		eth.Call(&w3types.Message{To: w3.APtr(erc1155), Func: funcSupportsInterface, Args: []any{interfaceERC1155}}, nil, nil).Returns(&out1155),
+	)
+	// Reverts and empty returns mean the collection is not in that pallet.
+	// Other failures, such as a timeout of one of the calls, say nothing
+	// about it.
+	if err != nil && !revert.Only(err) {
+		return "", "", err
+	}
+	is721, err := supportsInterface(out721)
+	if err != nil {
+		return "", "", err
+	}
+	is1155, err := supportsInterface(out1155)
+	if err != nil {
+		return "", "", err
+	}
 
-func AssetIdToERC20Address(assetId string) string {
-	assetIdInHex := new(big.Int)
-	assetIdInHex.SetString(assetId, 10)
-	assetIdHex := fmt.Sprintf("%08s", assetIdInHex.Text(16))
-	return common.HexToAddress(fmt.Sprintf("0xCCCCCCCC%s000000000000000000000000", strings.ToUpper(assetIdHex))).Hex()
+	switch {
+	case is721:
+		collections.Store(key, StandardERC721)
+		return StandardERC721, erc721, nil
+	case is1155:
+		collections.Store(key, StandardERC1155)
+		return StandardERC1155, erc1155, nil
+	}
+	return "", "", fmt.Errorf("%w: %s on %s", ErrCollectionNotFound, collectionId, network)
 }
 
-func CollectionIdToERC721Address(collectionId string) string {
-	collectionIdInHex := new(big.Int)
-	collectionIdInHex.SetString(collectionId, 10)
-	collectionIdHex := fmt.Sprintf("%08s", collectionIdInHex.Text(16))
-	return common.HexToAddress(fmt.Sprintf("0xAAAAAAAA%s000000000000000000000000", strings.ToUpper(collectionIdHex))).Hex()
+// supportsInterface decodes the output of a supportsInterface call. Calls that
+// reverted or hit an address without code have no output.
+func supportsInterface(output []byte) (bool, error) {
+	var supported bool
+	if len(output) == 0 {
+		return false, nil
+	}
+	if err := funcSupportsInterface.DecodeReturns(output, &supported); err != nil {
+		return false, fmt.Errorf("supportsInterface: %w", err)
+	}
+	return supported, nil
 }
 
-func CollectionIdToERC1155Address(collectionId string) string {
-	collectionIdInHex := new(big.Int)
-	collectionIdInHex.SetString(collectionId, 10)
-	collectionIdHex := fmt.Sprintf("%08s", collectionIdInHex.Text(16))
-	return common.HexToAddress(fmt.Sprintf("0xBBBBBBBB%s000000000000000000000000", strings.ToUpper(collectionIdHex))).Hex()
+func collectionAddress(standard string, collectionId string) (string, error) {
+	if standard == StandardERC1155 {
+		return CollectionIdToERC1155Address(collectionId)
+	}
+	return CollectionIdToERC721Address(collectionId)
 }
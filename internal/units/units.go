@@ -0,0 +1,142 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RawPrefix marks an amount given in the smallest unit of the token.
+	RawPrefix = "wei:"
+
+	// maxExponent bounds the exponent of scientific notation, so amounts
+	// cannot allocate arbitrarily large numbers.
+	maxExponent = 256
+)
+
+// suffixes are the magnitudes an amount may end with, such as 1.5k. M is
+// upper case only, so it cannot be mistaken for milli.
+var suffixes = map[byte]int{'k': 3, 'K': 3, 'M': 6}
+
+var (
+	ErrInvalidDecimal   = errors.New("amount must be a non-negative decimal number, optionally in scientific notation, with a k or M suffix or prefixed with wei:")
+	ErrTooManyDecimals  = errors.New("amount has more fractional digits than the token has decimals")
+	ErrExponentTooLarge = fmt.Errorf("exponent must be between -%d and %d", maxExponent, maxExponent)
+)
+
+// Amount is an exact non-negative decimal amount, value * 10^exp, or a raw
+// amount in the smallest unit of a token.
+type Amount struct {
+	text  string
+	value *big.Int
+	exp   int
+	raw   bool
+}
+
+// Parse parses a decimal amount such as "1", "0.25", "1.5e3" or "1.5k", or a raw
+// amount in the smallest unit such as "wei:1500000". No floating point is
+// involved, so every amount is represented exactly.
+func Parse(amount string) (Amount, error) {
+	if digits, ok := strings.CutPrefix(amount, RawPrefix); ok {
+		if digits == "" || !isDigits(digits) {
+			return Amount{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, amount)
+		}
+		value, _ := new(big.Int).SetString(digits, 10)
+		return Amount{text: amount, value: value, raw: true}, nil
+	}
+
+	number := amount
+	exp := 0
+	if n := len(number); n > 0 {
+		if shift, ok := suffixes[number[n-1]]; ok {
+			number, exp = number[:n-1], shift
+		}
+	}
+
+	mantissa, exponent, scientific := strings.Cut(strings.ToLower(number), "e")
+	if scientific {
+		var err error
+		// A suffix and an exponent together are ambiguous.
+		if exp != 0 {
+			return Amount{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, amount)
+		}
+		exp, err = strconv.Atoi(exponent)
+		if err != nil {
+			return Amount{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, amount)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return Amount{}, fmt.Errorf("%w: %q", ErrExponentTooLarge, amount)
+		}
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, amount)
+	}
+
+	// Trailing zeros move into the exponent, so -exp is the number of
+	// fractional digits the amount needs.
+	digits := whole + fraction
+	exp -= len(fraction)
+	trimmed := strings.TrimRight(digits, "0")
+	if trimmed == "" {
+		return Amount{text: amount, value: new(big.Int)}, nil
+	}
+	exp += len(digits) - len(trimmed)
+	value, _ := new(big.Int).SetString(trimmed, 10)
+	return Amount{text: amount, value: value, exp: exp}, nil
+}
+
+// Units converts the amount into the smallest unit of a token with the given
+// decimals. Amounts that are not a whole number of smallest units are
+// rejected.
+func (a Amount) Units(decimals uint8) (*big.Int, error) {
+	if a.value == nil {
+		return nil, ErrInvalidDecimal
+	}
+	if a.raw {
+		return new(big.Int).Set(a.value), nil
+	}
+
+	shift := a.exp + int(decimals)
+	if shift >= 0 {
+		return new(big.Int).Mul(a.value, pow10(shift)), nil
+	}
+
+	units, remainder := new(big.Int).QuoRem(a.value, pow10(-shift), new(big.Int))
+	if remainder.Sign() != 0 {
+		return nil, fmt.Errorf("%w: %s needs %d fractional digits, the token has %d decimals", ErrTooManyDecimals, a.text, -a.exp, decimals)
+	}
+	return units, nil
+}
+
+// String returns the amount as given to Parse.
+func (a Amount) String() string {
+	return a.text
+}
+
+// ParseDecimal parses an amount with Parse and converts it into the smallest
+// unit of a token with the given decimals.
+func ParseDecimal(amount string, decimals uint8) (*big.Int, error) {
+	a, err := Parse(amount)
+	if err != nil {
+		return nil, err
+	}
+	return a.Units(decimals)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals uint8
+		want     string
+		err      bool
+	}{
+		{"1", 18, "1000000000000000000", false},
+		{"0.5", 18, "500000000000000000", false},
+		{".5", 6, "500000", false},
+		{"2.", 6, "2000000", false},
+		{"123.456789", 6, "123456789", false},
+		{"0.0000001", 6, "", true},
+		{"1", 0, "1", false},
+		{"1.5", 0, "", true},
+		{"", 18, "", true},
+		{".", 18, "", true},
+		{"-1", 18, "", true},
+		{"1.2.3", 18, "", true},
+		{"1e3", 0, "1000", false},
+		{"1.5E3", 6, "1500000000", false},
+		{"25e-2", 18, "250000000000000000", false},
+		{"1e-7", 6, "", true},
+		{"1e+2", 0, "100", false},
+		{"1e", 18, "", true},
+		{"1e1000", 18, "", true},
+		{"0.10", 1, "1", false},
+		{"0", 18, "0", false},
+		{"0.000", 0, "0", false},
+		{"1000e-3", 0, "1", false},
+		{"1.5k", 6, "1500000000", false},
+		{"2K", 0, "2000", false},
+		{"0.25M", 0, "250000", false},
+		{"1.5m", 0, "", true},
+		{"k", 0, "", true},
+		{"1e3k", 0, "", true},
+		{"1.0005k", 0, "", true},
+		{"wei:1500000", 18, "1500000", false},
+		{"wei:1.5", 18, "", true},
+		{"wei:", 18, "", true},
+	}
+
+	for _, test := range tests {
+		value, err := ParseDecimal(test.amount, test.decimals)
+		if test.err {
+			assert.Error(t, err, "Should fail for %q", test.amount)
+			continue
+		}
+		assert.NoError(t, err, "Should parse %q", test.amount)
+		want, _ := new(big.Int).SetString(test.want, 10)
+		assert.Equal(t, want, value, "Value should match for %q", test.amount)
+	}
+}
+
+func TestTooManyDecimals(t *testing.T) {
+	amount, err := Parse("0.0000001")
+	assert.NoError(t, err)
+	_, err = amount.Units(6)
+	assert.ErrorIs(t, err, ErrTooManyDecimals)
+	assert.Contains(t, err.Error(), "0.0000001 needs 7 fractional digits, the token has 6 decimals")
+
+	amount, err = Parse("10e-3")
+	assert.NoError(t, err)
+	_, err = amount.Units(1)
+	assert.Contains(t, err.Error(), "needs 2 fractional digits")
+}
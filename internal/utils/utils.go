@@ -3,11 +3,9 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/big"
 	"os"
 	"path/filepath"
-	"regexp"
 
 	"github.com/FN00EU/vulcan-one/internal/shared"
 )
@@ -33,16 +31,3 @@ func LoadConfiguration(filename string) (*shared.Configuration, error) {
 
 	return &config, nil
 }
-
-func IsValidERC1155Format(str string) bool {
-	erc1155Format, err := regexp.Compile(`^\d+_\d+(&\d+_\d+)*$|^\d+-\d+(&\d+-\d+)*$`)
-	if err != nil {
-		log.Printf("invalid regex")
-	}
-
-	if erc1155Format.MatchString(str) {
-		return true
-	}
-
-	return false
-}
@@ -0,0 +1,336 @@
+package w3client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FN00EU/vulcan-one/internal/metrics"
+	"github.com/FN00EU/vulcan-one/internal/shared"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+const (
+	ProbeInterval = 15 * time.Second
+	ProbeTimeout  = 5 * time.Second
+	CallTimeout   = 10 * time.Second
+
+	// maxFailures consecutive errors or a lag of more than maxBlockLag blocks
+	// behind the best endpoint mark an endpoint as unhealthy.
+	maxFailures = 3
+	maxBlockLag = 10
+
+	// Penalties are expressed in milliseconds so they can be added to the
+	// measured latency of an endpoint.
+	failurePenalty = 1000
+	lagPenalty     = 250
+)
+
+// endpoint holds the connection and the health statistics of one RPC URL.
+type endpoint struct {
+	url         string
+	priority    int
+	client      *w3.Client
+	latency     time.Duration
+	failures    int
+	blockNumber uint64
+	lastError   error
+}
+
+// EndpointStatus is a snapshot of the health of one RPC URL in a Pool.
+type EndpointStatus struct {
+	URL         string        `json:"url"`
+	Connected   bool          `json:"connected"`
+	Healthy     bool          `json:"healthy"`
+	Latency     time.Duration `json:"latency"`
+	Failures    int           `json:"failures"`
+	BlockNumber uint64        `json:"blockNumber"`
+	Score       float64       `json:"score"`
+}
+
+// Pool holds one client per configured RPC URL of a network. It probes every
+// endpoint in the background, scores it on latency, errors and block lag, and
+// sends each call to the best endpoint, failing over to the next one when the
+// call cannot be delivered.
+type Pool struct {
+	network   string
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	head      uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPool dials every URL of a network. URLs that cannot be dialed are kept
+// and redialed by the next probe.
+func NewPool(network string, rpcURLs []string) *Pool {
+	p := &Pool{
+		network: network,
+		stop:    make(chan struct{}),
+	}
+	for i, rpcURL := range rpcURLs {
+		e := &endpoint{url: rpcURL, priority: i}
+		client, err := w3.Dial(rpcURL)
+		if err != nil {
+			log.Printf("Error dialing %s: %v\n", rpcURL, err)
+			e.lastError = err
+			e.failures = maxFailures
+		} else {
+			e.client = client
+		}
+		p.endpoints = append(p.endpoints, e)
+	}
+	return p
+}
+
+// Start runs the background health probe every interval until Close is called.
+func (p *Pool) Start(interval time.Duration) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.Probe(context.Background())
+			}
+		}
+	}()
+}
+
+// Probe requests the block number from every endpoint concurrently and updates
+// their scores. Endpoints that are not connected are redialed first.
+func (p *Pool) Probe(ctx context.Context) {
+	p.mu.RLock()
+	endpoints := append([]*endpoint(nil), p.endpoints...)
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			p.probeEndpoint(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, e := range p.endpoints {
+		up := 0.0
+		if p.healthy(e) {
+			up = 1
+		}
+		metrics.EndpointUp.WithLabelValues(p.network, metrics.Endpoint(e.url)).Set(up)
+	}
+}
+
+func (p *Pool) probeEndpoint(ctx context.Context, e *endpoint) {
+	p.mu.RLock()
+	client := e.client
+	p.mu.RUnlock()
+
+	if client == nil {
+		var err error
+		client, err = w3.Dial(e.url)
+		if err != nil {
+			p.recordFailure(e, err)
+			return
+		}
+		p.mu.Lock()
+		e.client = client
+		p.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	var blockNumber big.Int
+	start := time.Now()
+	if err := client.CallCtx(ctx, eth.BlockNumber().Returns(&blockNumber)); err != nil {
+		p.recordFailure(e, err)
+		return
+	}
+	p.recordSuccess(e, time.Since(start))
+
+	number := blockNumber.Uint64()
+	p.mu.Lock()
+	e.blockNumber = number
+	if number > p.head {
+		p.head = number
+	}
+	p.mu.Unlock()
+	metrics.EndpointBlockNumber.WithLabelValues(p.network, metrics.Endpoint(e.url)).Set(float64(number))
+}
+
+func (p *Pool) recordSuccess(e *endpoint, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = (e.latency*7 + latency) / 8
+	}
+	if e.failures > 0 {
+		e.failures--
+	}
+	e.lastError = nil
+}
+
+func (p *Pool) recordFailure(e *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e.failures++
+	e.lastError = err
+}
+
+// score returns the cost of using an endpoint, lower is better. Must be called
+// with p.mu held.
+func (p *Pool) score(e *endpoint) float64 {
+	score := float64(e.latency.Milliseconds())
+	score += float64(e.failures * failurePenalty)
+	if p.head > e.blockNumber && e.blockNumber > 0 {
+		score += float64((p.head - e.blockNumber) * lagPenalty)
+	}
+	return score
+}
+
+// healthy must be called with p.mu held.
+func (p *Pool) healthy(e *endpoint) bool {
+	if e.client == nil || e.failures >= maxFailures {
+		return false
+	}
+	return e.blockNumber == 0 || p.head-e.blockNumber <= maxBlockLag
+}
+
+// ordered returns the connected endpoints, healthy ones first, each group
+// sorted by score and then by configuration priority.
+func (p *Pool) ordered() []*endpoint {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var endpoints []*endpoint
+	for _, e := range p.endpoints {
+		if e.client != nil {
+			endpoints = append(endpoints, e)
+		}
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		hi, hj := p.healthy(endpoints[i]), p.healthy(endpoints[j])
+		if hi != hj {
+			return hi
+		}
+		si, sj := p.score(endpoints[i]), p.score(endpoints[j])
+		if si != sj {
+			return si < sj
+		}
+		return endpoints[i].priority < endpoints[j].priority
+	})
+	return endpoints
+}
+
+// CallCtx sends the calls to the best endpoint. If the request cannot be
+// delivered, the next endpoint is tried. Errors of individual calls
+// (w3.CallErrors), such as reverts, are returned without failover.
+func (p *Pool) CallCtx(ctx context.Context, calls ...w3types.Caller) error {
+	metrics.BatchSize.WithLabelValues(p.network).Observe(float64(len(calls)))
+
+	var lastErr error
+	for _, e := range p.ordered() {
+		p.mu.RLock()
+		client := e.client
+		p.mu.RUnlock()
+		if client == nil {
+			// Closed since the endpoints were ordered.
+			continue
+		}
+
+		endpoint := metrics.Endpoint(e.url)
+		callCtx, cancel := context.WithTimeout(ctx, CallTimeout)
+		start := time.Now()
+		err := client.CallCtx(callCtx, calls...)
+		cancel()
+		metrics.RPCCallDuration.WithLabelValues(p.network, endpoint).Observe(time.Since(start).Seconds())
+
+		var callErrs w3.CallErrors
+		if errors.As(err, &callErrs) {
+			metrics.RPCCallErrors.WithLabelValues(p.network, endpoint).Inc()
+		}
+		if err == nil || errors.As(err, &callErrs) {
+			p.recordSuccess(e, time.Since(start))
+			return err
+		}
+
+		metrics.RPCCallErrors.WithLabelValues(p.network, endpoint).Inc()
+		p.recordFailure(e, err)
+		log.Printf(shared.LogFailover, e.url, err)
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf(shared.ErrNoHealthyEndpoint, p.network)
+	}
+	return lastErr
+}
+
+// Call is like CallCtx with ctx equal to context.Background().
+func (p *Pool) Call(calls ...w3types.Caller) error {
+	return p.CallCtx(context.Background(), calls...)
+}
+
+// Status returns the health of every endpoint in configuration order.
+func (p *Pool) Status() []EndpointStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		status = append(status, EndpointStatus{
+			URL:         e.url,
+			Connected:   e.client != nil,
+			Healthy:     p.healthy(e),
+			Latency:     e.latency,
+			Failures:    e.failures,
+			BlockNumber: e.blockNumber,
+			Score:       p.score(e),
+		})
+	}
+	return status
+}
+
+// Close stops the background probe and closes every connection.
+func (p *Pool) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.endpoints {
+		if e.client == nil {
+			continue
+		}
+		if err := e.client.Close(); err != nil {
+			log.Printf("Error: %s", err.Error())
+		}
+		e.client = nil
+	}
+	return nil
+}
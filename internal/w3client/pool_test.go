@@ -0,0 +1,103 @@
+package w3client_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FN00EU/vulcan-one/internal/w3client"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+// mockRPC serves eth_blockNumber with the given block number and counts the
+// requests it receives. A block number of 0 makes every request fail with 503.
+func mockRPC(blockNumber string) (*httptest.Server, *int32) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if blockNumber == "0x0" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  blockNumber,
+		})
+	}))
+	return srv, &hits
+}
+
+func TestPoolFailover(t *testing.T) {
+	dead, deadHits := mockRPC("0x0")
+	defer dead.Close()
+	alive, aliveHits := mockRPC("0x10")
+	defer alive.Close()
+
+	pool := w3client.NewPool("test", []string{dead.URL, alive.URL})
+	defer pool.Close()
+
+	// Test case 1: Call fails over to the backup endpoint
+	var blockNumber big.Int
+	err := pool.Call(eth.BlockNumber().Returns(&blockNumber))
+	assert.NoError(t, err, "Call should fail over to the working endpoint")
+	assert.Equal(t, int64(16), blockNumber.Int64(), "Block number should match")
+	assert.Equal(t, int32(1), atomic.LoadInt32(deadHits), "Primary endpoint should be tried once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(aliveHits), "Backup endpoint should be called")
+
+	// Test case 2: After a probe the failing endpoint is ranked last
+	pool.Probe(context.Background())
+	status := pool.Status()
+	assert.Len(t, status, 2, "Status should list both endpoints")
+	assert.Greater(t, status[0].Score, status[1].Score, "Failing endpoint should score worse")
+	assert.Equal(t, uint64(16), status[1].BlockNumber, "Probe should record the block number")
+
+	atomic.StoreInt32(deadHits, 0)
+	err = pool.Call(eth.BlockNumber().Returns(&blockNumber))
+	assert.NoError(t, err, "Call should succeed")
+	assert.Equal(t, int32(0), atomic.LoadInt32(deadHits), "Unhealthy endpoint should not be tried first")
+}
+
+func TestPoolBlockLag(t *testing.T) {
+	lagging, _ := mockRPC("0x1")
+	defer lagging.Close()
+	synced, _ := mockRPC("0x100")
+	defer synced.Close()
+
+	pool := w3client.NewPool("test", []string{lagging.URL, synced.URL})
+	defer pool.Close()
+
+	pool.Probe(context.Background())
+	status := pool.Status()
+	assert.False(t, status[0].Healthy, "Lagging endpoint should be unhealthy")
+	assert.True(t, status[1].Healthy, "Synced endpoint should be healthy")
+}
+
+func TestPoolNoEndpoint(t *testing.T) {
+	dead, _ := mockRPC("0x0")
+	defer dead.Close()
+
+	pool := w3client.NewPool("test", []string{dead.URL})
+	defer pool.Close()
+
+	var blockNumber big.Int
+	err := pool.Call(eth.BlockNumber().Returns(&blockNumber))
+	assert.Error(t, err, "Should return an error when every endpoint fails")
+}
@@ -1,6 +1,7 @@
 package w3client
 
 import (
+	"context"
 	"log"
 	"math/big"
 
@@ -9,50 +10,60 @@ import (
 	"github.com/lmittmann/w3/module/eth"
 )
 
+// CreateClientWithPriority returns a client for the first URL that can be
+// dialed and answers eth_blockNumber, trying the URLs in order.
 func CreateClientWithPriority(rpcURLs []string) (*w3.Client, error) {
-	var client *w3.Client
 	var err error
 	var blockNumber big.Int
 	for _, rpcURL := range rpcURLs {
+		var client *w3.Client
 		client, err = w3.Dial(rpcURL)
-		if err == nil {
-			if err := client.Call(eth.BlockNumber().Returns(&blockNumber)); err == nil {
-				log.Printf(shared.LogConnected, rpcURL, blockNumber.Int64())
-				break
-			} else {
-				log.Printf("Error making initial call to %s: %v\n", rpcURL, err)
-				errClose := client.Close()
-				if errClose != nil {
-					log.Printf("Error: %s", err.Error())
-				}
-				return nil, err
+		if err != nil {
+			log.Printf("Error dialing %s: %v\n", rpcURL, err)
+			continue
+		}
+		if err = client.Call(eth.BlockNumber().Returns(&blockNumber)); err != nil {
+			log.Printf("Error making initial call to %s: %v\n", rpcURL, err)
+			if errClose := client.Close(); errClose != nil {
+				log.Printf("Error: %s", errClose.Error())
 			}
+			continue
 		}
-	}
-	if err != nil {
-		return nil, err
+		log.Printf(shared.LogConnected, rpcURL, blockNumber.Int64())
+		return client, nil
 	}
 
-	return client, nil
+	return nil, err
 }
 
-func SetupClients(networks map[string][]string) map[string]*w3.Client {
-	clients := make(map[string]*w3.Client)
+// SetupClients creates a Pool for every network, probes all of its endpoints
+// once and starts the background health probe.
+func SetupClients(networks map[string][]string) map[string]shared.RPCClient {
+	clients := make(map[string]shared.RPCClient)
 	for network, rpcURLs := range networks {
-		client, err := CreateClientWithPriority(rpcURLs)
-		if err != nil {
-			log.Printf("Error creating client for %s: %v", network, err)
-			continue
+		pool := NewPool(network, rpcURLs)
+		pool.Probe(context.Background())
+
+		healthy := false
+		for _, status := range pool.Status() {
+			if status.Healthy {
+				log.Printf(shared.LogConnected, status.URL, status.BlockNumber)
+				healthy = true
+			}
 		}
+		if !healthy {
+			log.Printf("Error creating client for %s: no healthy RPC endpoint, retrying in background", network)
+		}
+
+		pool.Start(ProbeInterval)
 		shared.ClientMutex.Lock()
-		clients[network] = client
+		clients[network] = pool
 		shared.ClientMutex.Unlock()
-
 	}
 	return clients
 }
 
-func CloseClients(clients map[string]*w3.Client) {
+func CloseClients(clients map[string]shared.RPCClient) {
 	shared.ClientMutex.Lock()
 	defer shared.ClientMutex.Unlock()
 
@@ -63,23 +74,3 @@ func CloseClients(clients map[string]*w3.Client) {
 		}
 	}
 }
-
-func RedialClient(network string) error {
-	shared.ClientMutex.Lock()
-	defer shared.ClientMutex.Unlock()
-
-	newClient, err := CreateClientWithPriority(shared.Config.EVMnetworks[network])
-	if err != nil {
-		return err
-	}
-
-	if existingClient, ok := shared.Clients[network]; ok {
-		err := existingClient.Close()
-		if err != nil {
-			log.Printf("Error: %s", err.Error())
-		}
-	}
-
-	shared.Clients[network] = newClient
-	return nil
-}